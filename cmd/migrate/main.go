@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"task-manager/config"
+	"task-manager/internal/models"
+	"task-manager/pkg/database"
+)
+
+const usage = `Usage: migrate <command> [migration-id]
+
+Commands:
+  up [id]    Apply all pending migrations, or up to and including id
+  down [id]  Roll back the last applied migration, or back to (excluding) id`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	// Load environment variables from .env and .env.{APP_ENV}
+	config.LoadEnvFiles()
+
+	// Initialize database connection
+	db, err := database.InitDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	migrator := models.NewMigrator(db)
+	command := os.Args[1]
+	target := ""
+	if len(os.Args) > 2 {
+		target = os.Args[2]
+	}
+
+	switch {
+	case command == "up" && target == "":
+		err = migrator.Migrate()
+	case command == "up":
+		err = migrator.MigrateTo(target)
+	case command == "down" && target == "":
+		err = migrator.RollbackLast()
+	case command == "down":
+		err = migrator.RollbackTo(target)
+	default:
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatalf("Migration %s failed: %v", command, err)
+	}
+	log.Printf("Migration %s completed successfully", command)
+}
@@ -1,97 +1,698 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+// DefaultJWTSecret is the placeholder secret used when JWT_SECRET is not set.
+// It must never be used in production.
+const DefaultJWTSecret = "default_jwt_secret_change_me"
+
 // Config represents the application configuration
 type Config struct {
-	App      AppConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Logging  LoggingConfig
+	App         AppConfig         `yaml:"app"`
+	Database    DatabaseConfig    `yaml:"database"`
+	JWT         JWTConfig         `yaml:"jwt"`
+	Logging     LoggingConfig     `yaml:"logging"`
+	Reminders   ReminderConfig    `yaml:"reminders"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Sessions    SessionsConfig    `yaml:"sessions"`
+	Tasks       TasksConfig       `yaml:"tasks"`
+	Security    SecurityConfig    `yaml:"security"`
+	Pagination  PaginationConfig  `yaml:"pagination"`
+
+	// loadErr records a config file that could not be loaded so that
+	// Validate can report it
+	loadErr error
 }
 
 // AppConfig contains application-related configuration
 type AppConfig struct {
-	Port string
-	Env  string
+	Port string `yaml:"port"`
+	Env  string `yaml:"env"`
+	// Timezone is the IANA zone (e.g. "UTC", "Europe/Berlin") that
+	// timestamps in API responses are expressed in
+	Timezone string `yaml:"timezone"`
+	// TimeFormat is the Go time layout timestamps in API responses are
+	// formatted with
+	TimeFormat string `yaml:"time_format"`
+	// SwaggerEnabled controls the /swagger docs route; when unset it is
+	// enabled everywhere except production
+	SwaggerEnabled *bool `yaml:"swagger_enabled"`
+	// HSTSMaxAge is the Strict-Transport-Security max-age sent over TLS in
+	// production; zero disables the header
+	HSTSMaxAge time.Duration `yaml:"hsts_max_age"`
+	// MaxRequestBodySize is the largest request body accepted, in bytes
+	MaxRequestBodySize int `yaml:"max_request_body_size"`
+	// TrustedProxies lists the IPs and CIDR ranges of proxies whose
+	// X-Forwarded-For header is trusted for the client IP; when empty no
+	// proxy is trusted
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	// MaintenanceMode starts the server with writes refused; admins can
+	// switch it at runtime
+	MaintenanceMode bool `yaml:"maintenance_mode"`
+	// MaintenanceRetryAfter is the delay sent in Retry-After while
+	// maintenance mode refuses a request
+	MaintenanceRetryAfter time.Duration `yaml:"maintenance_retry_after"`
 }
 
 // DatabaseConfig contains database-related configuration
 type DatabaseConfig struct {
-	Host      string
-	Port      string
-	User      string
-	Password  string
-	Name      string
-	Charset   string
-	ParseTime bool
-	Loc       string
+	Driver       string   `yaml:"driver"`
+	Host         string   `yaml:"host"`
+	Port         string   `yaml:"port"`
+	User         string   `yaml:"user"`
+	Password     string   `yaml:"password"`
+	Name         string   `yaml:"name"`
+	Charset      string   `yaml:"charset"`
+	ParseTime    bool     `yaml:"parse_time"`
+	Loc          string   `yaml:"loc"`
+	ReplicaHosts []string `yaml:"replica_hosts"`
 }
 
 // JWTConfig contains JWT-related configuration
 type JWTConfig struct {
-	Secret    string
-	ExpiresIn time.Duration
+	Secret    string        `yaml:"secret"`
+	ExpiresIn time.Duration `yaml:"expires_in"`
+	// RememberMeExpiresIn is the lifetime of tokens issued to logins that
+	// ask to be remembered
+	RememberMeExpiresIn time.Duration `yaml:"remember_me_expires_in"`
+	// Leeway is how far a token's expiry and not-before times may be
+	// exceeded, to tolerate clock skew between servers
+	Leeway time.Duration `yaml:"leeway"`
+	// VerificationSecrets are previous secrets whose tokens are still
+	// accepted, so that Secret can be rotated without logging everyone out.
+	// New tokens are always signed with Secret.
+	VerificationSecrets []string `yaml:"verification_secrets"`
 }
 
 // LoggingConfig contains logging-related configuration
 type LoggingConfig struct {
-	Level string
+	Level string `yaml:"level"`
+	// Bodies logs request and response bodies at the debug level, with
+	// credential fields redacted
+	Bodies bool `yaml:"bodies"`
+	// BodyMaxSize is how many bytes of each logged body are kept
+	BodyMaxSize int `yaml:"body_max_size"`
+}
+
+// ReminderConfig contains task reminder scheduler configuration
+type ReminderConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval"`
+}
+
+// IdempotencyConfig contains idempotency key cleanup configuration
+type IdempotencyConfig struct {
+	// CleanupInterval is how often expired idempotency keys are deleted
+	CleanupInterval time.Duration `yaml:"cleanup_interval"`
+}
+
+// SessionsConfig contains login session cleanup configuration
+type SessionsConfig struct {
+	// CleanupInterval is how often expired login sessions are deleted
+	CleanupInterval time.Duration `yaml:"cleanup_interval"`
+}
+
+// TasksConfig contains task validation rules and limits
+type TasksConfig struct {
+	// RejectPastDueDates rejects new tasks and due date changes with a due
+	// date more than DueDateGracePeriod in the past
+	RejectPastDueDates bool          `yaml:"reject_past_due_dates"`
+	DueDateGracePeriod time.Duration `yaml:"due_date_grace_period"`
+	// MaxTasksPerUser is how many tasks a user may own; zero means no limit
+	MaxTasksPerUser int `yaml:"max_tasks_per_user"`
+	// DefaultPriority is the priority of new tasks created without one:
+	// low, medium or high. Any other value falls back to medium.
+	DefaultPriority string `yaml:"default_priority"`
+	// WarnOnDuplicateTitle adds a warning to the response when a new task
+	// has the same title as one of the user's open tasks
+	WarnOnDuplicateTitle bool `yaml:"warn_on_duplicate_title"`
+	// TrashRetentionDays is how many days soft-deleted tasks are kept before
+	// they are purged for good; zero keeps them forever
+	TrashRetentionDays int `yaml:"trash_retention_days"`
+	// TrashPurgeInterval is how often soft-deleted tasks past the retention
+	// period are looked for
+	TrashPurgeInterval time.Duration `yaml:"trash_purge_interval"`
 }
 
-var config *Config
+// SecurityConfig contains password hashing configuration
+type SecurityConfig struct {
+	// BcryptCost is the cost used when hashing passwords; existing hashes
+	// with a lower cost are upgraded on the user's next login
+	BcryptCost int `yaml:"bcrypt_cost"`
+	// LoginMaxAttempts consecutive failed logins for an account within
+	// LoginAttemptWindow lock it for LoginLockoutDuration
+	LoginMaxAttempts     int           `yaml:"login_max_attempts"`
+	LoginAttemptWindow   time.Duration `yaml:"login_attempt_window"`
+	LoginLockoutDuration time.Duration `yaml:"login_lockout_duration"`
+	// Password policy enforced when a password is set or changed
+	PasswordMinLength      int  `yaml:"password_min_length"`
+	PasswordRequireDigit   bool `yaml:"password_require_digit"`
+	PasswordRequireUpper   bool `yaml:"password_require_upper"`
+	PasswordRequireSpecial bool `yaml:"password_require_special"`
+	// RegistrationEnabled lets anyone register; when off, only admins can
+	// create users
+	RegistrationEnabled bool `yaml:"registration_enabled"`
+	// UserRateLimit is how many requests an authenticated user may make per
+	// UserRateLimitWindow; zero disables the limit
+	UserRateLimit       int           `yaml:"user_rate_limit"`
+	UserRateLimitWindow time.Duration `yaml:"user_rate_limit_window"`
+}
+
+// PaginationConfig contains the page sizes used by list endpoints
+type PaginationConfig struct {
+	DefaultPageSize int `yaml:"default_page_size"`
+	MaxPageSize     int `yaml:"max_page_size"`
+}
+
+var (
+	// configMu guards config, which may be loaded and read concurrently
+	configMu sync.RWMutex
+	config   *Config
+
+	// envFilesOnce makes LoadEnvFiles load the files only once
+	envFilesOnce sync.Once
+)
+
+// LoadEnvFiles sets environment variables from .env.{APP_ENV} (e.g.
+// .env.production) and .env, if they exist. Values in the environment-specific
+// file take precedence over .env, and variables already set in the process
+// environment over both. APP_ENV itself may be set in .env. Only the first
+// call has an effect.
+func LoadEnvFiles() {
+	envFilesOnce.Do(func() {
+		base, err := godotenv.Read(".env")
+		if err != nil {
+			log.Printf("Warning: .env file not found or could not be loaded: %v", err)
+		}
+
+		appEnv := os.Getenv("APP_ENV")
+		if appEnv == "" {
+			appEnv = base["APP_ENV"]
+		}
+
+		// godotenv never overrides a variable that is already set, so the
+		// file loaded first wins
+		var files []string
+		if appEnv != "" {
+			if _, err := os.Stat(".env." + appEnv); err == nil {
+				files = append(files, ".env."+appEnv)
+			}
+		}
+		if base != nil {
+			files = append(files, ".env")
+		}
+		if len(files) == 0 {
+			return
+		}
 
-// Load initializes the configuration
+		if err := godotenv.Load(files...); err != nil {
+			log.Printf("Warning: environment files could not be loaded: %v", err)
+			return
+		}
+		log.Printf("Loaded environment variables from %s", strings.Join(files, ", "))
+	})
+}
+
+// Load initializes the configuration. Values come from the file named by
+// CONFIG_FILE, if set, with environment variables taking precedence.
+// Later calls return the configuration loaded first.
 func Load() *Config {
-	// Load .env file if it exists
-	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: .env file not found or could not be loaded: %v", err)
-	}
+	LoadEnvFiles()
+
+	configMu.Lock()
+	defer configMu.Unlock()
 
 	// Initialize config singleton if not already initialized
 	if config == nil {
-		config = &Config{
-			App: AppConfig{
-				Port: getEnvOrDefault("APP_PORT", "8080"),
-				Env:  getEnvOrDefault("APP_ENV", "development"),
-			},
-			Database: DatabaseConfig{
-				Host:      getEnvOrDefault("DB_HOST", "localhost"),
-				Port:      getEnvOrDefault("DB_PORT", "3306"),
-				User:      getEnvOrDefault("DB_USER", "root"),
-				Password:  getEnvOrDefault("DB_PASSWORD", ""),
-				Name:      getEnvOrDefault("DB_NAME", "task_manager"),
-				Charset:   getEnvOrDefault("DB_CHARSET", "utf8mb4"),
-				ParseTime: getBoolEnvOrDefault("DB_PARSE_TIME", true),
-				Loc:       getEnvOrDefault("DB_LOC", "Local"),
-			},
-			JWT: JWTConfig{
-				Secret:    getEnvOrDefault("JWT_SECRET", "default_jwt_secret_change_me"),
-				ExpiresIn: getDurationEnvOrDefault("JWT_EXPIRES_IN", 24*time.Hour),
-			},
-			Logging: LoggingConfig{
-				Level: getEnvOrDefault("LOG_LEVEL", "info"),
-			},
+		if path := os.Getenv("CONFIG_FILE"); path != "" {
+			cfg, err := readFile(path)
+			if err != nil {
+				// Fall back to the environment; Validate reports the failure
+				cfg = applyEnv(defaultConfig())
+				cfg.loadErr = err
+			}
+			config = cfg
+		} else {
+			config = applyEnv(defaultConfig())
 		}
 	}
 
 	return config
 }
 
+// LoadFromFile reads the configuration from a YAML or JSON file and makes it
+// the current configuration. Settings missing from the file keep their
+// defaults, and environment variables override values from the file.
+func LoadFromFile(path string) (*Config, error) {
+	cfg, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	configMu.Lock()
+	config = cfg
+	configMu.Unlock()
+	return cfg, nil
+}
+
+// readFile reads the configuration from a YAML or JSON file and applies the
+// environment on top of it
+func readFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	// JSON is valid YAML, so a single decoder handles both formats
+	cfg := defaultConfig()
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	log.Printf("Loaded configuration from %s", path)
+	return applyEnv(cfg), nil
+}
+
+// defaultConfig returns the configuration used when nothing is set
+func defaultConfig() *Config {
+	return &Config{
+		App: AppConfig{
+			Port:                  "8080",
+			Env:                   "development",
+			Timezone:              "UTC",
+			TimeFormat:            time.RFC3339,
+			HSTSMaxAge:            365 * 24 * time.Hour,
+			MaxRequestBodySize:    1 << 20,
+			MaintenanceRetryAfter: 5 * time.Minute,
+		},
+		Database: DatabaseConfig{
+			Driver:    "mysql",
+			Host:      "localhost",
+			Port:      "3306",
+			User:      "root",
+			Name:      "task_manager",
+			Charset:   "utf8mb4",
+			ParseTime: true,
+			Loc:       "Local",
+		},
+		JWT: JWTConfig{
+			Secret:              DefaultJWTSecret,
+			ExpiresIn:           24 * time.Hour,
+			RememberMeExpiresIn: 30 * 24 * time.Hour,
+		},
+		Logging: LoggingConfig{
+			Level:       "info",
+			Bodies:      false,
+			BodyMaxSize: 4096,
+		},
+		Reminders: ReminderConfig{
+			PollInterval: time.Minute,
+		},
+		Idempotency: IdempotencyConfig{
+			CleanupInterval: time.Hour,
+		},
+		Sessions: SessionsConfig{
+			CleanupInterval: time.Hour,
+		},
+		Tasks: TasksConfig{
+			DueDateGracePeriod: 5 * time.Minute,
+			DefaultPriority:    "medium",
+			TrashPurgeInterval: time.Hour,
+		},
+		Security: SecurityConfig{
+			BcryptCost:           10,
+			LoginMaxAttempts:     5,
+			LoginAttemptWindow:   15 * time.Minute,
+			LoginLockoutDuration: 15 * time.Minute,
+			PasswordMinLength:    6,
+			RegistrationEnabled:  true,
+			UserRateLimitWindow:  time.Minute,
+		},
+		Pagination: PaginationConfig{
+			DefaultPageSize: 10,
+			MaxPageSize:     100,
+		},
+	}
+}
+
+// applyEnv overrides cfg with any values set in the environment
+func applyEnv(cfg *Config) *Config {
+	cfg.App.Port = getEnvOrDefault("APP_PORT", cfg.App.Port)
+	cfg.App.Env = getEnvOrDefault("APP_ENV", cfg.App.Env)
+	cfg.App.Timezone = getEnvOrDefault("APP_TIMEZONE", cfg.App.Timezone)
+	cfg.App.TimeFormat = getEnvOrDefault("TIME_FORMAT", cfg.App.TimeFormat)
+	if os.Getenv("SWAGGER_ENABLED") != "" {
+		swaggerEnabled := getBoolEnvOrDefault("SWAGGER_ENABLED", cfg.App.Env != "production")
+		cfg.App.SwaggerEnabled = &swaggerEnabled
+	}
+	cfg.App.HSTSMaxAge = getDurationEnvOrDefault("HSTS_MAX_AGE", cfg.App.HSTSMaxAge)
+	cfg.App.MaxRequestBodySize = getIntEnvOrDefault("MAX_REQUEST_BODY_SIZE", cfg.App.MaxRequestBodySize)
+	cfg.App.TrustedProxies = getListEnvOrDefault("TRUSTED_PROXIES", cfg.App.TrustedProxies)
+	cfg.App.MaintenanceMode = getBoolEnvOrDefault("MAINTENANCE_MODE", cfg.App.MaintenanceMode)
+	cfg.App.MaintenanceRetryAfter = getDurationEnvOrDefault("MAINTENANCE_RETRY_AFTER", cfg.App.MaintenanceRetryAfter)
+
+	cfg.Database.Driver = strings.ToLower(getEnvOrDefault("DB_DRIVER", cfg.Database.Driver))
+	cfg.Database.Host = getEnvOrDefault("DB_HOST", cfg.Database.Host)
+	cfg.Database.Port = getEnvOrDefault("DB_PORT", cfg.Database.Port)
+	cfg.Database.User = getEnvOrDefault("DB_USER", cfg.Database.User)
+	cfg.Database.Password = getEnvOrDefault("DB_PASSWORD", cfg.Database.Password)
+	cfg.Database.Name = getEnvOrDefault("DB_NAME", cfg.Database.Name)
+	cfg.Database.Charset = getEnvOrDefault("DB_CHARSET", cfg.Database.Charset)
+	cfg.Database.ParseTime = getBoolEnvOrDefault("DB_PARSE_TIME", cfg.Database.ParseTime)
+	cfg.Database.Loc = getEnvOrDefault("DB_LOC", cfg.Database.Loc)
+	cfg.Database.ReplicaHosts = getListEnvOrDefault("DB_REPLICA_HOSTS", cfg.Database.ReplicaHosts)
+
+	cfg.JWT.Secret = getEnvOrDefault("JWT_SECRET", cfg.JWT.Secret)
+	cfg.JWT.ExpiresIn = getDurationEnvOrDefault("JWT_EXPIRES_IN", cfg.JWT.ExpiresIn)
+	cfg.JWT.RememberMeExpiresIn = getDurationEnvOrDefault("JWT_REMEMBER_ME_EXPIRES_IN", cfg.JWT.RememberMeExpiresIn)
+	cfg.JWT.Leeway = getDurationEnvOrDefault("JWT_LEEWAY", cfg.JWT.Leeway)
+	cfg.JWT.VerificationSecrets = getListEnvOrDefault("JWT_SECRETS", cfg.JWT.VerificationSecrets)
+
+	cfg.Logging.Level = getEnvOrDefault("LOG_LEVEL", cfg.Logging.Level)
+	cfg.Logging.Bodies = getBoolEnvOrDefault("LOG_BODIES", cfg.Logging.Bodies)
+	cfg.Logging.BodyMaxSize = getIntEnvOrDefault("LOG_BODY_MAX_SIZE", cfg.Logging.BodyMaxSize)
+
+	cfg.Reminders.PollInterval = getDurationEnvOrDefault("REMINDER_POLL_INTERVAL", cfg.Reminders.PollInterval)
+	cfg.Idempotency.CleanupInterval = getDurationEnvOrDefault("IDEMPOTENCY_CLEANUP_INTERVAL", cfg.Idempotency.CleanupInterval)
+	cfg.Sessions.CleanupInterval = getDurationEnvOrDefault("SESSION_CLEANUP_INTERVAL", cfg.Sessions.CleanupInterval)
+	cfg.Tasks.RejectPastDueDates = getBoolEnvOrDefault("REJECT_PAST_DUE_DATES", cfg.Tasks.RejectPastDueDates)
+	cfg.Tasks.DueDateGracePeriod = getDurationEnvOrDefault("DUE_DATE_GRACE_PERIOD", cfg.Tasks.DueDateGracePeriod)
+	cfg.Tasks.MaxTasksPerUser = getIntEnvOrDefault("MAX_TASKS_PER_USER", cfg.Tasks.MaxTasksPerUser)
+	cfg.Tasks.DefaultPriority = validPriorityOrDefault(getEnvOrDefault("DEFAULT_TASK_PRIORITY", cfg.Tasks.DefaultPriority))
+	cfg.Tasks.WarnOnDuplicateTitle = getBoolEnvOrDefault("WARN_ON_DUPLICATE_TITLE", cfg.Tasks.WarnOnDuplicateTitle)
+	cfg.Tasks.TrashRetentionDays = getIntEnvOrDefault("TRASH_RETENTION_DAYS", cfg.Tasks.TrashRetentionDays)
+	cfg.Tasks.TrashPurgeInterval = getDurationEnvOrDefault("TRASH_PURGE_INTERVAL", cfg.Tasks.TrashPurgeInterval)
+
+	cfg.Security.BcryptCost = getIntEnvOrDefault("BCRYPT_COST", cfg.Security.BcryptCost)
+	cfg.Security.LoginMaxAttempts = getIntEnvOrDefault("LOGIN_MAX_ATTEMPTS", cfg.Security.LoginMaxAttempts)
+	cfg.Security.LoginAttemptWindow = getDurationEnvOrDefault("LOGIN_ATTEMPT_WINDOW", cfg.Security.LoginAttemptWindow)
+	cfg.Security.LoginLockoutDuration = getDurationEnvOrDefault("LOGIN_LOCKOUT_DURATION", cfg.Security.LoginLockoutDuration)
+	cfg.Security.PasswordMinLength = getIntEnvOrDefault("PASSWORD_MIN_LENGTH", cfg.Security.PasswordMinLength)
+	cfg.Security.PasswordRequireDigit = getBoolEnvOrDefault("PASSWORD_REQUIRE_DIGIT", cfg.Security.PasswordRequireDigit)
+	cfg.Security.PasswordRequireUpper = getBoolEnvOrDefault("PASSWORD_REQUIRE_UPPER", cfg.Security.PasswordRequireUpper)
+	cfg.Security.PasswordRequireSpecial = getBoolEnvOrDefault("PASSWORD_REQUIRE_SPECIAL", cfg.Security.PasswordRequireSpecial)
+	cfg.Security.RegistrationEnabled = getBoolEnvOrDefault("REGISTRATION_ENABLED", cfg.Security.RegistrationEnabled)
+	cfg.Security.UserRateLimit = getIntEnvOrDefault("USER_RATE_LIMIT", cfg.Security.UserRateLimit)
+	cfg.Security.UserRateLimitWindow = getDurationEnvOrDefault("USER_RATE_LIMIT_WINDOW", cfg.Security.UserRateLimitWindow)
+
+	cfg.Pagination.DefaultPageSize = getIntEnvOrDefault("DEFAULT_PAGE_SIZE", cfg.Pagination.DefaultPageSize)
+	cfg.Pagination.MaxPageSize = getIntEnvOrDefault("MAX_PAGE_SIZE", cfg.Pagination.MaxPageSize)
+
+	return cfg
+}
+
+// Validate checks the configuration for missing or unsafe values and returns
+// an error listing every problem found, or nil if the configuration is usable
+func (c *Config) Validate() error {
+	var problems []string
+
+	if c.loadErr != nil {
+		problems = append(problems, c.loadErr.Error())
+	}
+
+	if port, err := strconv.Atoi(c.App.Port); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("APP_PORT must be a valid port number, got %q", c.App.Port))
+	}
+
+	if _, err := time.LoadLocation(c.App.Timezone); err != nil {
+		problems = append(problems, fmt.Sprintf("APP_TIMEZONE must be a valid IANA time zone, got %q", c.App.Timezone))
+	}
+	if !isTimeLayout(c.App.TimeFormat) {
+		problems = append(problems, fmt.Sprintf("TIME_FORMAT must be a Go time layout with a date, a time to the second and a zone offset, e.g. %q, got %q", time.RFC3339, c.App.TimeFormat))
+	}
+
+	if c.App.HSTSMaxAge < 0 {
+		problems = append(problems, "HSTS_MAX_AGE must not be negative")
+	}
+	if c.App.MaxRequestBodySize <= 0 {
+		problems = append(problems, "MAX_REQUEST_BODY_SIZE must be a positive number of bytes")
+	}
+	for _, proxy := range c.App.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				problems = append(problems, fmt.Sprintf("TRUSTED_PROXIES must contain IP addresses or CIDR ranges, got %q", proxy))
+			}
+		}
+	}
+	if c.App.MaintenanceRetryAfter <= 0 {
+		problems = append(problems, "MAINTENANCE_RETRY_AFTER must be a positive duration")
+	}
+
+	switch c.Logging.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, fmt.Sprintf("LOG_LEVEL must be one of debug, info, warn, error, got %q", c.Logging.Level))
+	}
+	if c.Logging.BodyMaxSize < 1 {
+		problems = append(problems, fmt.Sprintf("LOG_BODY_MAX_SIZE must be at least 1, got %d", c.Logging.BodyMaxSize))
+	}
+
+	if c.JWT.Secret == "" {
+		problems = append(problems, "JWT_SECRET is required")
+	}
+	if c.JWT.ExpiresIn <= 0 {
+		problems = append(problems, "JWT_EXPIRES_IN must be a positive duration")
+	}
+	if c.JWT.RememberMeExpiresIn < c.JWT.ExpiresIn {
+		problems = append(problems, "JWT_REMEMBER_ME_EXPIRES_IN must not be shorter than JWT_EXPIRES_IN")
+	}
+	if c.JWT.Leeway < 0 {
+		problems = append(problems, "JWT_LEEWAY must not be negative")
+	}
+	for _, secret := range c.JWT.VerificationSecrets {
+		if secret == "" {
+			problems = append(problems, "JWT_SECRETS must not contain empty secrets")
+			break
+		}
+	}
+
+	if c.Reminders.PollInterval <= 0 {
+		problems = append(problems, "REMINDER_POLL_INTERVAL must be a positive duration")
+	}
+	if c.Idempotency.CleanupInterval <= 0 {
+		problems = append(problems, "IDEMPOTENCY_CLEANUP_INTERVAL must be a positive duration")
+	}
+	if c.Sessions.CleanupInterval <= 0 {
+		problems = append(problems, "SESSION_CLEANUP_INTERVAL must be a positive duration")
+	}
+	if c.Tasks.DueDateGracePeriod < 0 {
+		problems = append(problems, "DUE_DATE_GRACE_PERIOD must not be negative")
+	}
+	if c.Tasks.MaxTasksPerUser < 0 {
+		problems = append(problems, "MAX_TASKS_PER_USER must not be negative")
+	}
+	if c.Tasks.TrashRetentionDays < 0 {
+		problems = append(problems, "TRASH_RETENTION_DAYS must not be negative")
+	}
+	if c.Tasks.TrashPurgeInterval <= 0 {
+		problems = append(problems, "TRASH_PURGE_INTERVAL must be a positive duration")
+	}
+
+	// bcrypt accepts costs from 4 to 31
+	if c.Security.BcryptCost < 4 || c.Security.BcryptCost > 31 {
+		problems = append(problems, fmt.Sprintf("BCRYPT_COST must be between 4 and 31, got %d", c.Security.BcryptCost))
+	}
+
+	if c.Security.LoginMaxAttempts < 1 {
+		problems = append(problems, fmt.Sprintf("LOGIN_MAX_ATTEMPTS must be at least 1, got %d", c.Security.LoginMaxAttempts))
+	}
+	if c.Security.LoginAttemptWindow <= 0 {
+		problems = append(problems, "LOGIN_ATTEMPT_WINDOW must be a positive duration")
+	}
+	if c.Security.LoginLockoutDuration <= 0 {
+		problems = append(problems, "LOGIN_LOCKOUT_DURATION must be a positive duration")
+	}
+	if c.Security.UserRateLimit < 0 {
+		problems = append(problems, fmt.Sprintf("USER_RATE_LIMIT must not be negative, got %d", c.Security.UserRateLimit))
+	}
+	if c.Security.UserRateLimitWindow <= 0 {
+		problems = append(problems, "USER_RATE_LIMIT_WINDOW must be a positive duration")
+	}
+	// bcrypt ignores everything past the first 72 bytes of a password
+	if c.Security.PasswordMinLength < 1 || c.Security.PasswordMinLength > 72 {
+		problems = append(problems, fmt.Sprintf("PASSWORD_MIN_LENGTH must be between 1 and 72, got %d", c.Security.PasswordMinLength))
+	}
+
+	if c.Pagination.MaxPageSize < 1 {
+		problems = append(problems, fmt.Sprintf("MAX_PAGE_SIZE must be at least 1, got %d", c.Pagination.MaxPageSize))
+	}
+	if c.Pagination.DefaultPageSize < 1 || c.Pagination.DefaultPageSize > c.Pagination.MaxPageSize {
+		problems = append(problems, fmt.Sprintf("DEFAULT_PAGE_SIZE must be between 1 and MAX_PAGE_SIZE, got %d", c.Pagination.DefaultPageSize))
+	}
+
+	switch c.Database.Driver {
+	case "mysql":
+		if c.Database.Host == "" {
+			problems = append(problems, "DB_HOST is required")
+		}
+		if c.Database.User == "" {
+			problems = append(problems, "DB_USER is required")
+		}
+		if c.Database.Name == "" {
+			problems = append(problems, "DB_NAME is required")
+		}
+	case "sqlite":
+		if c.Database.Name == "" {
+			problems = append(problems, "DB_NAME is required")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("DB_DRIVER must be one of mysql, sqlite, got %q", c.Database.Driver))
+	}
+
+	// Defaults that are convenient locally are unsafe in production
+	if c.App.Env == "production" {
+		if c.JWT.Secret == DefaultJWTSecret {
+			problems = append(problems, "JWT_SECRET must be changed from the default value in production")
+		}
+		if c.Database.Driver == "mysql" && c.Database.Password == "" {
+			problems = append(problems, "DB_PASSWORD is required in production")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// redacted replaces secret values in SafeString
+const redacted = "[REDACTED]"
+
+// SafeString returns the effective configuration as a JSON object for
+// logging. The JWT secrets and database password are replaced by
+// "[REDACTED]", or left empty when unset.
+func (c *Config) SafeString() string {
+	redact := func(secret string) string {
+		if secret == "" {
+			return ""
+		}
+		return redacted
+	}
+
+	view := struct {
+		Env              string   `json:"env"`
+		Port             string   `json:"port"`
+		Timezone         string   `json:"timezone"`
+		TimeFormat       string   `json:"time_format"`
+		TrustedProxies   []string `json:"trusted_proxies"`
+		DBDriver         string   `json:"db_driver"`
+		DBHost           string   `json:"db_host"`
+		DBPort           string   `json:"db_port"`
+		DBUser           string   `json:"db_user"`
+		DBPassword       string   `json:"db_password"`
+		DBName           string   `json:"db_name"`
+		DBReplicaHosts   []string `json:"db_replica_hosts"`
+		LogLevel         string   `json:"log_level"`
+		LogBodies        bool     `json:"log_bodies"`
+		JWTSecret        string   `json:"jwt_secret"`
+		JWTSecrets       []string `json:"jwt_secrets"`
+		JWTExpiresIn     string   `json:"jwt_expires_in"`
+		JWTRememberMe    string   `json:"jwt_remember_me_expires_in"`
+		JWTLeeway        string   `json:"jwt_leeway"`
+		ReminderInterval string   `json:"reminder_poll_interval"`
+		CleanupInterval  string   `json:"idempotency_cleanup_interval"`
+		SessionCleanup   string   `json:"session_cleanup_interval"`
+	}{
+		Env:              c.App.Env,
+		Port:             c.App.Port,
+		Timezone:         c.App.Timezone,
+		TimeFormat:       c.App.TimeFormat,
+		TrustedProxies:   c.App.TrustedProxies,
+		DBDriver:         c.Database.Driver,
+		DBHost:           c.Database.Host,
+		DBPort:           c.Database.Port,
+		DBUser:           c.Database.User,
+		DBPassword:       redact(c.Database.Password),
+		DBName:           c.Database.Name,
+		DBReplicaHosts:   c.Database.ReplicaHosts,
+		LogLevel:         c.Logging.Level,
+		LogBodies:        c.Logging.Bodies,
+		JWTSecret:        redact(c.JWT.Secret),
+		JWTSecrets:       make([]string, len(c.JWT.VerificationSecrets)),
+		JWTExpiresIn:     c.JWT.ExpiresIn.String(),
+		JWTRememberMe:    c.JWT.RememberMeExpiresIn.String(),
+		JWTLeeway:        c.JWT.Leeway.String(),
+		ReminderInterval: c.Reminders.PollInterval.String(),
+		CleanupInterval:  c.Idempotency.CleanupInterval.String(),
+		SessionCleanup:   c.Sessions.CleanupInterval.String(),
+	}
+
+	for i, secret := range c.JWT.VerificationSecrets {
+		view.JWTSecrets[i] = redact(secret)
+	}
+
+	// Marshalling strings, bools and string slices can't fail
+	out, _ := json.Marshal(view)
+	return string(out)
+}
+
 // GetConfig returns the current configuration
 func GetConfig() *Config {
-	if config == nil {
+	configMu.RLock()
+	cfg := config
+	configMu.RUnlock()
+
+	if cfg == nil {
 		return Load()
 	}
-	return config
+	return cfg
+}
+
+var (
+	locationMu   sync.Mutex
+	locationName string
+	location     *time.Location
+)
+
+// Location returns the time zone configured by APP_TIMEZONE, falling back
+// to UTC if it cannot be loaded
+func Location() *time.Location {
+	name := GetConfig().App.Timezone
+
+	locationMu.Lock()
+	defer locationMu.Unlock()
+
+	if location == nil || name != locationName {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			loc = time.UTC
+		}
+		location, locationName = loc, name
+	}
+	return location
+}
+
+// isTimeLayout reports whether layout formats timestamps without losing the
+// instant they denote, to the second, so that clients can parse them back
+func isTimeLayout(layout string) bool {
+	ref := time.Date(2021, time.November, 23, 14, 5, 9, 0, time.FixedZone("", 90*60))
+	parsed, err := time.Parse(layout, ref.Format(layout))
+	return err == nil && parsed.Equal(ref)
+}
+
+// IsSwaggerEnabled returns true if the Swagger API docs should be served
+func IsSwaggerEnabled() bool {
+	if enabled := GetConfig().App.SwaggerEnabled; enabled != nil {
+		return *enabled
+	}
+	return !IsProduction()
 }
 
 // IsProduction returns true if the application is running in production mode
@@ -147,6 +748,33 @@ func getIntEnvOrDefault(key string, defaultValue int) int {
 	return intValue
 }
 
+// validPriorityOrDefault returns priority if it is a task priority, and
+// medium with a warning otherwise
+func validPriorityOrDefault(priority string) string {
+	switch priority {
+	case "low", "medium", "high":
+		return priority
+	}
+	log.Printf("Warning: DEFAULT_TASK_PRIORITY %q is not one of low, medium, high, using medium", priority)
+	return "medium"
+}
+
+// getListEnvOrDefault retrieves a comma-separated list environment variable or returns a default value if not set
+func getListEnvOrDefault(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // getDurationEnvOrDefault retrieves a duration environment variable or returns a default value if not set
 func getDurationEnvOrDefault(key string, defaultValue time.Duration) time.Duration {
 	value := os.Getenv(key)
@@ -166,4 +794,4 @@ func getDurationEnvOrDefault(key string, defaultValue time.Duration) time.Durati
 		return defaultValue
 	}
 	return duration
-}
\ No newline at end of file
+}
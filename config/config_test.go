@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// resetConfig forgets the loaded configuration so that the next Load reads
+// it again
+func resetConfig(t *testing.T) {
+	t.Helper()
+	configMu.Lock()
+	config = nil
+	configMu.Unlock()
+	t.Cleanup(func() {
+		configMu.Lock()
+		config = nil
+		configMu.Unlock()
+	})
+}
+
+// concurrently runs f in n goroutines at once and waits for them to finish
+func concurrently(n int, f func(i int)) {
+	var start, done sync.WaitGroup
+	start.Add(1)
+	for i := 0; i < n; i++ {
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			start.Wait()
+			f(i)
+		}(i)
+	}
+	start.Done()
+	done.Wait()
+}
+
+func TestLoadConcurrent(t *testing.T) {
+	resetConfig(t)
+
+	configs := make([]*Config, 16)
+	concurrently(len(configs), func(i int) {
+		if i%2 == 0 {
+			configs[i] = Load()
+		} else {
+			configs[i] = GetConfig()
+		}
+	})
+
+	for _, cfg := range configs {
+		if cfg == nil || cfg != configs[0] {
+			t.Fatal("concurrent Load and GetConfig calls returned different configurations")
+		}
+	}
+}
+
+func TestLoadFromFileConcurrent(t *testing.T) {
+	resetConfig(t)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("app:\n  port: \"9090\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	concurrently(16, func(i int) {
+		switch i % 4 {
+		case 0:
+			if _, err := LoadFromFile(path); err != nil {
+				t.Errorf("LoadFromFile failed: %v", err)
+			}
+		case 1:
+			Load()
+		case 2:
+			Location()
+		default:
+			GetConfig()
+		}
+	})
+
+	if port := GetConfig().App.Port; port != "9090" && os.Getenv("APP_PORT") == "" {
+		t.Errorf("got port %q, want the one from the file", port)
+	}
+}
+
+func TestDefaultPriorityFallback(t *testing.T) {
+	for value, want := range map[string]string{
+		"high":   "high",
+		"urgent": "medium",
+		"HIGH":   "medium",
+	} {
+		resetConfig(t)
+		t.Setenv("DEFAULT_TASK_PRIORITY", value)
+
+		cfg := Load()
+		if cfg.Tasks.DefaultPriority != want {
+			t.Errorf("DEFAULT_TASK_PRIORITY=%s: got default priority %q, want %q", value, cfg.Tasks.DefaultPriority, want)
+		}
+		if err := cfg.Validate(); err != nil && strings.Contains(err.Error(), "DEFAULT_TASK_PRIORITY") {
+			t.Errorf("DEFAULT_TASK_PRIORITY=%s: Validate reported %v", value, err)
+		}
+	}
+}
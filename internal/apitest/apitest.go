@@ -0,0 +1,146 @@
+// Package apitest runs the API against a fresh in-memory SQLite database, so
+// that tests and tools can exercise it end to end through its HTTP routes.
+// It installs its database as the global one, so harnesses must not be used
+// concurrently.
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"task-manager/internal/middlewares"
+	"task-manager/internal/models"
+	"task-manager/internal/routes"
+	"task-manager/internal/services"
+	"task-manager/pkg/database"
+	"task-manager/pkg/utils"
+)
+
+// Password is the password of the users created by SeedUser
+const Password = "password123"
+
+// Harness is the API router together with the database it serves
+type Harness struct {
+	DB     *gorm.DB
+	Router *gin.Engine
+}
+
+// New opens an empty in-memory database, migrates it and sets up the routes
+// as the server does
+func New() (*Harness, error) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.InitTestDB()
+	if err != nil {
+		return nil, err
+	}
+	if err := models.SetupModels(db); err != nil {
+		return nil, fmt.Errorf("failed to setup database models: %w", err)
+	}
+
+	router := gin.New()
+	router.Use(middlewares.RecoveryMiddleware())
+	routes.SetupRoutes(router)
+
+	return &Harness{DB: db, Router: router}, nil
+}
+
+// Do sends a request to the API and returns the recorded response. path is
+// relative to the server root, e.g. "/api/tasks/". A non-empty token is sent
+// as a bearer token, and a non-nil body is sent as JSON.
+func (h *Harness) Do(method, path, token string, body interface{}) (*httptest.ResponseRecorder, error) {
+	return h.DoContext(context.Background(), method, path, token, body)
+}
+
+// DoContext is like Do but sends the request with ctx, e.g. one returned by
+// database.WithQueryCounter
+func (h *Harness) DoContext(ctx context.Context, method, path, token string, body interface{}) (*httptest.ResponseRecorder, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader).WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	h.Router.ServeHTTP(w, req)
+	return w, nil
+}
+
+// Login logs in through the login endpoint and returns the token issued
+func (h *Harness) Login(email, password string) (string, error) {
+	w, err := h.Do(http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    email,
+		"password": password,
+	})
+	if err != nil {
+		return "", err
+	}
+	if w.Code != http.StatusOK {
+		return "", fmt.Errorf("login failed with status %d: %s", w.Code, w.Body.String())
+	}
+
+	var auth struct {
+		Token string `json:"token"`
+	}
+	if err := Decode(w, &auth); err != nil {
+		return "", err
+	}
+	return auth.Token, nil
+}
+
+// SeedUser creates a user with the given username, the email address
+// <username>@example.com and Password
+func (h *Harness) SeedUser(username string) (*models.User, error) {
+	user := models.User{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: Password,
+		Role:     models.RoleUser,
+	}
+	if err := h.DB.Create(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	return &user, nil
+}
+
+// Token mints a token for the user without logging in. It isn't tied to a
+// session, so it stays valid until it expires.
+func (h *Harness) Token(userID uint) (string, error) {
+	return utils.GenerateToken(userID)
+}
+
+// SeedTask creates a task owned by the user, as the create endpoint would
+func (h *Harness) SeedTask(userID uint, title string) (*models.Task, error) {
+	return services.NewTaskService().CreateTask(context.Background(), services.TaskRequest{
+		Title:    title,
+		Priority: models.PriorityMedium,
+		UserID:   userID,
+	})
+}
+
+// Decode decodes the JSON response body into v
+func Decode(w *httptest.ResponseRecorder, v interface{}) error {
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		return fmt.Errorf("failed to decode response body %q: %w", w.Body.String(), err)
+	}
+	return nil
+}
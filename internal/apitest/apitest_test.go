@@ -0,0 +1,230 @@
+package apitest_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"task-manager/config"
+	"task-manager/internal/apitest"
+	"task-manager/pkg/database"
+)
+
+// task is the part of a task response the tests look at
+type task struct {
+	ID      uint   `json:"id"`
+	Title   string `json:"title"`
+	Status  string `json:"status"`
+	Version int    `json:"version"`
+}
+
+// newHarness returns a harness on a fresh database, closed when the test ends
+func newHarness(t *testing.T) *apitest.Harness {
+	t.Helper()
+	h, err := apitest.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Reset() })
+	return h
+}
+
+// do sends a request and fails the test unless the response has the wanted
+// status
+func do(t *testing.T, h *apitest.Harness, method, path, token string, body interface{}, want int) *httptest.ResponseRecorder {
+	t.Helper()
+	w, err := h.Do(method, path, token, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != want {
+		t.Fatalf("%s %s: got status %d, want %d: %s", method, path, w.Code, want, w.Body.String())
+	}
+	return w
+}
+
+// decode decodes the response body into v
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := apitest.Decode(w, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// errorCode returns the code of an error response
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	decode(t, w, &body)
+	return body.Error.Code
+}
+
+func TestRegisterLoginAndTaskLifecycle(t *testing.T) {
+	h := newHarness(t)
+
+	w := do(t, h, http.MethodPost, "/api/v1/auth/register", "", map[string]string{
+		"username": "alice",
+		"email":    "alice@example.com",
+		"password": apitest.Password,
+	}, http.StatusCreated)
+	var registered struct {
+		Token     string `json:"token"`
+		ExpiresAt string `json:"expires_at"`
+		User      struct {
+			Username string `json:"username"`
+		} `json:"user"`
+	}
+	decode(t, w, &registered)
+	if registered.Token == "" || registered.ExpiresAt == "" || registered.User.Username != "alice" {
+		t.Errorf("register returned %+v", registered)
+	}
+
+	token, err := h.Login("alice@example.com", apitest.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create
+	w = do(t, h, http.MethodPost, "/api/v1/tasks/", token, map[string]string{"title": "Write report"}, http.StatusCreated)
+	var created task
+	decode(t, w, &created)
+	path := fmt.Sprintf("/api/v1/tasks/%d", created.ID)
+
+	// Read
+	w = do(t, h, http.MethodGet, path, token, nil, http.StatusOK)
+	var read task
+	decode(t, w, &read)
+	if read.Title != "Write report" || read.Status != "todo" {
+		t.Errorf("read %q in status %q, want the new task", read.Title, read.Status)
+	}
+
+	// Update
+	w = do(t, h, http.MethodPut, path, token, map[string]interface{}{"title": "Write the report", "version": read.Version}, http.StatusOK)
+	var updated task
+	decode(t, w, &updated)
+	if updated.Title != "Write the report" {
+		t.Errorf("updated title is %q", updated.Title)
+	}
+
+	// Change status
+	w = do(t, h, http.MethodPatch, path+"/status", token, map[string]string{"status": "completed"}, http.StatusOK)
+	var completed task
+	decode(t, w, &completed)
+	if completed.Status != "completed" {
+		t.Errorf("status is %q after completing the task", completed.Status)
+	}
+
+	// Delete
+	do(t, h, http.MethodDelete, path, token, nil, http.StatusOK)
+	do(t, h, http.MethodGet, path, token, nil, http.StatusNotFound)
+}
+
+func TestRegisterDisabled(t *testing.T) {
+	h := newHarness(t)
+	security := &config.GetConfig().Security
+	security.RegistrationEnabled = false
+	t.Cleanup(func() { security.RegistrationEnabled = true })
+
+	w := do(t, h, http.MethodPost, "/api/v1/auth/register", "", map[string]string{
+		"username": "alice",
+		"email":    "alice@example.com",
+		"password": apitest.Password,
+	}, http.StatusForbidden)
+	if code := errorCode(t, w); code != "registration_disabled" {
+		t.Errorf("got error code %q, want registration_disabled", code)
+	}
+}
+
+func TestAuthFailures(t *testing.T) {
+	h := newHarness(t)
+	alice, err := h.SeedUser("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := h.SeedUser("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seeded, err := h.SeedTask(alice.ID, "Alice's task")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobToken, err := h.Token(bob.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.Login("alice@example.com", "wrong password"); err == nil {
+		t.Error("logging in with a wrong password succeeded")
+	}
+
+	for _, tc := range []struct {
+		name   string
+		token  string
+		status int
+		code   string
+	}{
+		{"no token", "", http.StatusUnauthorized, "unauthorized"},
+		{"malformed token", "not-a-token", http.StatusUnauthorized, "invalid_token"},
+		{"another user's task", bobToken, http.StatusNotFound, "task_not_found"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := do(t, h, http.MethodGet, fmt.Sprintf("/api/v1/tasks/%d", seeded.ID), tc.token, nil, tc.status)
+			if code := errorCode(t, w); code != tc.code {
+				t.Errorf("got error code %q, want %q", code, tc.code)
+			}
+		})
+	}
+}
+
+func TestTaskListPagination(t *testing.T) {
+	h := newHarness(t)
+	user, err := h.SeedUser("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 12; i++ {
+		if _, err := h.SeedTask(user.ID, fmt.Sprintf("Task %d", i+1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	token, err := h.Token(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		page, tasks int
+		next, prev  bool
+	}{
+		{page: 1, tasks: 5, next: true},
+		{page: 2, tasks: 5, next: true, prev: true},
+		{page: 3, tasks: 2, prev: true},
+	} {
+		w := do(t, h, http.MethodGet, fmt.Sprintf("/api/v1/tasks/?page=%d&page_size=5", tc.page), token, nil, http.StatusOK)
+		var list struct {
+			Tasks      []task `json:"tasks"`
+			Pagination struct {
+				CurrentPage int     `json:"current_page"`
+				TotalItems  int64   `json:"total_items"`
+				TotalPages  int64   `json:"total_pages"`
+				NextPageURL *string `json:"next_page_url"`
+				PrevPageURL *string `json:"prev_page_url"`
+			} `json:"pagination"`
+		}
+		decode(t, w, &list)
+
+		p := list.Pagination
+		if len(list.Tasks) != tc.tasks || p.CurrentPage != tc.page || p.TotalItems != 12 || p.TotalPages != 3 {
+			t.Errorf("page %d: got %d tasks, page %d of %d with %d items", tc.page, len(list.Tasks), p.CurrentPage, p.TotalPages, p.TotalItems)
+		}
+		if (p.NextPageURL != nil) != tc.next || (p.PrevPageURL != nil) != tc.prev {
+			t.Errorf("page %d: got next link %t and prev link %t", tc.page, p.NextPageURL != nil, p.PrevPageURL != nil)
+		}
+	}
+}
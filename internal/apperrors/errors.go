@@ -0,0 +1,136 @@
+package apperrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// AppError is an application error carrying an HTTP status and a stable,
+// machine-readable code that clients can switch on
+type AppError struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  map[string]string
+	Err     error
+}
+
+// Response represents the JSON body returned for an error
+type Response struct {
+	Error  ErrorBody         `json:"error"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// ErrorBody holds the code and human-readable message of an error response
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Predefined application errors
+var (
+	ErrBadRequest           = New(http.StatusBadRequest, "bad_request", "Bad request")
+	ErrValidation           = New(http.StatusUnprocessableEntity, "validation_failed", "Request validation failed")
+	ErrInvalidTaskID        = New(http.StatusBadRequest, "invalid_task_id", "Invalid task ID")
+	ErrIdempotencyKeyUsed   = New(http.StatusUnprocessableEntity, "idempotency_key_reused", "Idempotency-Key was already used for a different request")
+	ErrUnauthorized         = New(http.StatusUnauthorized, "unauthorized", "Unauthorized")
+	ErrInvalidCredentials   = New(http.StatusUnauthorized, "invalid_credentials", "Invalid email or password")
+	ErrInvalidToken         = New(http.StatusUnauthorized, "invalid_token", "Invalid token")
+	ErrTokenExpired         = New(http.StatusUnauthorized, "token_expired", "Token has expired")
+	ErrInvalidAPIKey        = New(http.StatusUnauthorized, "invalid_api_key", "Invalid API key")
+	ErrSessionRevoked       = New(http.StatusUnauthorized, "session_revoked", "Session has been revoked")
+	ErrForbidden            = New(http.StatusForbidden, "forbidden", "Forbidden")
+	ErrTaskLimitReached     = New(http.StatusForbidden, "task_limit_reached", "Task limit reached")
+	ErrRegistrationDisabled = New(http.StatusForbidden, "registration_disabled", "Registration is disabled; ask an administrator to create your account")
+	ErrNotFound             = New(http.StatusNotFound, "not_found", "Resource not found")
+	ErrTaskNotFound         = New(http.StatusNotFound, "task_not_found", "Task not found")
+	ErrUserNotFound         = New(http.StatusNotFound, "user_not_found", "User not found")
+	ErrAPIKeyNotFound       = New(http.StatusNotFound, "api_key_not_found", "API key not found")
+	ErrSessionNotFound      = New(http.StatusNotFound, "session_not_found", "Session not found")
+	ErrCollaboratorNotFound = New(http.StatusNotFound, "collaborator_not_found", "The task is not shared with that user")
+	ErrMethodNotAllowed     = New(http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+	ErrConflict             = New(http.StatusConflict, "conflict", "Resource already exists")
+	ErrUsernameTaken        = New(http.StatusConflict, "username_taken", "Username already exists")
+	ErrEmailTaken           = New(http.StatusConflict, "email_taken", "Email already exists")
+	ErrVersionConflict      = New(http.StatusConflict, "version_conflict", "Task was modified by another request")
+	ErrTaskBlocked          = New(http.StatusConflict, "task_blocked", "Task has incomplete dependencies")
+	ErrDependencyCycle      = New(http.StatusConflict, "dependency_cycle", "Dependency would create a cycle")
+	ErrDependencyExists     = New(http.StatusConflict, "dependency_exists", "Dependency already exists")
+	ErrPayloadTooLarge      = New(http.StatusRequestEntityTooLarge, "payload_too_large", "Request body too large")
+	ErrVersionRequired      = New(http.StatusPreconditionRequired, "version_required", "The version of the task being updated is required")
+	ErrAccountLocked        = New(http.StatusTooManyRequests, "account_locked", "Too many failed login attempts")
+	ErrRateLimited          = New(http.StatusTooManyRequests, "rate_limited", "Too many requests")
+	ErrInternal             = New(http.StatusInternalServerError, "internal_error", "Internal server error")
+	ErrMaintenance          = New(http.StatusServiceUnavailable, "maintenance", "The service is in maintenance mode; changes are temporarily disabled")
+)
+
+// New creates a new AppError
+func New(status int, code, message string) *AppError {
+	return &AppError{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// Error implements the error interface
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying cause, if any
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an AppError with the same code, so that
+// copies created by WithMessage or Wrap still match their sentinel
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
+}
+
+// WithMessage returns a copy of the error with a different message
+func (e *AppError) WithMessage(message string) *AppError {
+	clone := *e
+	clone.Message = message
+	return &clone
+}
+
+// WithFields returns a copy of the error carrying per-field messages
+func (e *AppError) WithFields(fields map[string]string) *AppError {
+	clone := *e
+	clone.Fields = fields
+	return &clone
+}
+
+// Wrap returns a copy of the error that records err as its cause.
+// The cause is never exposed to clients.
+func (e *AppError) Wrap(err error) *AppError {
+	clone := *e
+	clone.Err = err
+	return &clone
+}
+
+// Response returns the JSON body to send to clients for this error
+func (e *AppError) Response() Response {
+	return Response{
+		Error: ErrorBody{
+			Code:    e.Code,
+			Message: e.Message,
+		},
+		Errors: e.Fields,
+	}
+}
+
+// From converts any error into an AppError, treating unknown errors as internal
+func From(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return ErrInternal.Wrap(err)
+}
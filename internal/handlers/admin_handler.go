@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"task-manager/internal/apperrors"
+	"task-manager/internal/middlewares"
+	"task-manager/internal/models"
+	"task-manager/internal/services"
+	"task-manager/pkg/utils"
+)
+
+// CreateUserRequest represents the request body for creating a user
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=50"`
+	Email    string `json:"email" binding:"required,email"`
+	// Password is generated when omitted
+	Password string `json:"password" binding:"omitempty,min=6"`
+	// Role defaults to user
+	Role string `json:"role" binding:"omitempty,oneof=user admin"`
+}
+
+// UserCreatedResponse represents a user created by an admin. Password holds
+// the generated password, if one was generated; it is never shown again.
+type UserCreatedResponse struct {
+	models.User
+	Password string `json:"password,omitempty"`
+}
+
+// MarshalJSON adds the generated password to the user's own JSON, which
+// would otherwise replace the response's because models.User has a
+// MarshalJSON
+func (r UserCreatedResponse) MarshalJSON() ([]byte, error) {
+	user, err := json.Marshal(r.User)
+	if err != nil || r.Password == "" {
+		return user, err
+	}
+	password, err := json.Marshal(r.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	out := append(user[:len(user)-1], `,"password":`...)
+	out = append(out, password...)
+	return append(out, '}'), nil
+}
+
+// TransferTasksRequest represents the request body for transferring a
+// user's tasks
+type TransferTasksRequest struct {
+	ToUserID uint `json:"to_user_id" binding:"required"`
+}
+
+// TransferTasksResponse represents the response body for transferring a
+// user's tasks
+type TransferTasksResponse struct {
+	Transferred int64 `json:"transferred"`
+}
+
+// UnlockUserResponse represents the response body for unlocking a user's
+// account
+type UnlockUserResponse struct {
+	WasLocked bool `json:"was_locked"`
+}
+
+// MaintenanceModeRequest represents the request body for switching
+// maintenance mode
+type MaintenanceModeRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// MaintenanceModeResponse represents the response body for maintenance mode
+type MaintenanceModeResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// UserListResponse represents the response body for a paginated list of users
+type UserListResponse struct {
+	Users      []models.User  `json:"users"`
+	Pagination PaginationMeta `json:"pagination"`
+}
+
+// ListUsers lists all users. Admin only.
+//
+//	@Summary	List users
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Param		page		query		int	false	"Page number"		default(1)
+//	@Param		page_size	query		int	false	"Users per page"	default(10)
+//	@Success	200			{object}	UserListResponse
+//	@Failure	400			{object}	apperrors.Response
+//	@Failure	401			{object}	apperrors.Response
+//	@Failure	403			{object}	apperrors.Response
+//	@Failure	500			{object}	apperrors.Response
+//	@Router		/admin/users [get]
+func ListUsers(c *gin.Context) {
+	var pagination PaginationQuery
+	if err := bindQuery(c, &pagination); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	result, err := services.NewUserService().ListUsers(c.Request.Context(), pagination.Page, pagination.PageSize)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, UserListResponse{
+		Users:      result.Users,
+		Pagination: newPaginationMeta(c, result.CurrentPage, result.PageSize, result.TotalItems, result.TotalPages),
+	})
+}
+
+// CreateUser creates a user account, e.g. on an instance where registration
+// is disabled. Admin only.
+//
+//	@Summary		Create a user
+//	@Description	Creates an account the user can log in to with the given password. Without a password, one is generated and returned in the response, only this once. Works whether or not registration is enabled.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			user	body		CreateUserRequest	true	"The user's details"
+//	@Success		201		{object}	UserCreatedResponse
+//	@Failure		400		{object}	apperrors.Response
+//	@Failure		401		{object}	apperrors.Response
+//	@Failure		403		{object}	apperrors.Response
+//	@Failure		409		{object}	apperrors.Response
+//	@Failure		422		{object}	apperrors.Response
+//	@Failure		500		{object}	apperrors.Response
+//	@Router			/admin/users [post]
+func CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, validationError(err))
+		return
+	}
+
+	role := models.RoleUser
+	if req.Role != "" {
+		role = models.Role(req.Role)
+	}
+
+	var generated string
+	password := req.Password
+	if password == "" {
+		var err error
+		if generated, err = utils.GeneratePassword(); err != nil {
+			respondError(c, err)
+			return
+		}
+		password = generated
+	}
+
+	user, err := services.NewUserService().CreateUser(c.Request.Context(), services.UserCreateRequest{
+		Username: req.Username,
+		Email:    req.Email,
+		Password: password,
+		Role:     role,
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if adminID, ok := middlewares.GetUserID(c); ok {
+		log.Printf("User %d (%s) created by user %d (password generated: %t)", user.ID, user.Role, adminID, generated != "")
+	}
+
+	respondCreated(c, UserCreatedResponse{
+		User:     *user,
+		Password: generated,
+	})
+}
+
+// TransferTasks moves all of a user's tasks to another user. Admin only.
+//
+//	@Summary	Transfer a user's tasks
+//	@Tags		admin
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Param		id			path		int						true	"ID of the user whose tasks are moved"
+//	@Param		transfer	body		TransferTasksRequest	true	"User receiving the tasks"
+//	@Success	200			{object}	TransferTasksResponse
+//	@Failure	400			{object}	apperrors.Response
+//	@Failure	401			{object}	apperrors.Response
+//	@Failure	403			{object}	apperrors.Response
+//	@Failure	404			{object}	apperrors.Response
+//	@Failure	422			{object}	apperrors.Response
+//	@Failure	500			{object}	apperrors.Response
+//	@Router		/admin/users/{id}/transfer-tasks [post]
+func TransferTasks(c *gin.Context) {
+	fromUserID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrBadRequest.WithMessage("Invalid user ID"))
+		return
+	}
+
+	var req TransferTasksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, validationError(err))
+		return
+	}
+
+	transferred, err := services.NewTaskService().TransferOwnership(c.Request.Context(), uint(fromUserID), req.ToUserID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, TransferTasksResponse{
+		Transferred: transferred,
+	})
+}
+
+// UnlockUser lifts a lockout caused by too many failed logins and clears the
+// user's failed login count. Admin only.
+//
+//	@Summary		Unlock a user's account
+//	@Description	Lets a user locked out after too many failed logins log in again straight away. Unlocking an account that isn't locked only clears its failed login count.
+//	@Tags			admin
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"User ID"
+//	@Success		200	{object}	UnlockUserResponse
+//	@Failure		400	{object}	apperrors.Response
+//	@Failure		401	{object}	apperrors.Response
+//	@Failure		403	{object}	apperrors.Response
+//	@Failure		404	{object}	apperrors.Response
+//	@Failure		500	{object}	apperrors.Response
+//	@Router			/admin/users/{id}/unlock [post]
+func UnlockUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrBadRequest.WithMessage("Invalid user ID"))
+		return
+	}
+
+	wasLocked, err := services.NewUserService().ClearLoginLockout(c.Request.Context(), uint(userID))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if adminID, ok := middlewares.GetUserID(c); ok {
+		log.Printf("Login lockout of user %d cleared by user %d (was locked: %t)", userID, adminID, wasLocked)
+	}
+
+	respondOK(c, UnlockUserResponse{
+		WasLocked: wasLocked,
+	})
+}
+
+// GetMaintenanceMode reports whether maintenance mode is on. Admin only.
+//
+//	@Summary	Get maintenance mode
+//	@Tags		admin
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Success	200	{object}	MaintenanceModeResponse
+//	@Failure	401	{object}	apperrors.Response
+//	@Failure	403	{object}	apperrors.Response
+//	@Router		/admin/maintenance [get]
+func GetMaintenanceMode(c *gin.Context) {
+	respondOK(c, MaintenanceModeResponse{
+		Enabled: middlewares.InMaintenanceMode(),
+	})
+}
+
+// SetMaintenanceMode switches maintenance mode on or off. The switch only
+// affects the instance that handles the request. Admin only.
+//
+//	@Summary	Switch maintenance mode
+//	@Tags		admin
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Param		maintenance	body		MaintenanceModeRequest	true	"Whether maintenance mode is on"
+//	@Success	200			{object}	MaintenanceModeResponse
+//	@Failure	401			{object}	apperrors.Response
+//	@Failure	403			{object}	apperrors.Response
+//	@Failure	422			{object}	apperrors.Response
+//	@Router		/admin/maintenance [put]
+func SetMaintenanceMode(c *gin.Context) {
+	var req MaintenanceModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, validationError(err))
+		return
+	}
+
+	middlewares.SetMaintenanceMode(*req.Enabled)
+	if userID, ok := middlewares.GetUserID(c); ok {
+		log.Printf("Maintenance mode set to %t by user %d", *req.Enabled, userID)
+	}
+
+	respondOK(c, MaintenanceModeResponse{
+		Enabled: *req.Enabled,
+	})
+}
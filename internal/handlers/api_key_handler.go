@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"task-manager/internal/apperrors"
+	"task-manager/internal/middlewares"
+	"task-manager/internal/models"
+	"task-manager/internal/services"
+)
+
+// APIKeyRequest represents the request body for creating an API key
+type APIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyCreatedResponse represents a newly created API key. Key holds the
+// plaintext key, which is never shown again.
+type APIKeyCreatedResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// MarshalJSON adds the plaintext key to the API key's own JSON, which would
+// otherwise replace the response's because models.APIKey has a MarshalJSON
+func (r APIKeyCreatedResponse) MarshalJSON() ([]byte, error) {
+	apiKey, err := json.Marshal(r.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	key, err := json.Marshal(r.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := append(apiKey[:len(apiKey)-1], `,"key":`...)
+	out = append(out, key...)
+	return append(out, '}'), nil
+}
+
+// CreateAPIKey creates an API key for the authenticated user
+//
+//	@Summary	Create an API key
+//	@Tags		api-keys
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Param		api_key	body		APIKeyRequest	true	"API key to create"
+//	@Success	201		{object}	APIKeyCreatedResponse
+//	@Failure	400		{object}	apperrors.Response
+//	@Failure	401		{object}	apperrors.Response
+//	@Failure	422		{object}	apperrors.Response
+//	@Failure	500		{object}	apperrors.Response
+//	@Router		/me/api-keys [post]
+func CreateAPIKey(c *gin.Context) {
+	var req APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, validationError(err))
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		respondError(c, apperrors.ErrValidation.WithFields(map[string]string{
+			"expires_at": "must be in the future",
+		}))
+		return
+	}
+
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	apiKey, key, err := services.NewAPIKeyService().CreateAPIKey(services.APIKeyRequest{
+		Name:      req.Name,
+		ExpiresAt: req.ExpiresAt,
+		UserID:    userID,
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondCreated(c, APIKeyCreatedResponse{
+		APIKey: *apiKey,
+		Key:    key,
+	})
+}
+
+// ListAPIKeys lists the authenticated user's API keys
+//
+//	@Summary	List API keys
+//	@Tags		api-keys
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Success	200	{array}		models.APIKey
+//	@Failure	401	{object}	apperrors.Response
+//	@Failure	500	{object}	apperrors.Response
+//	@Router		/me/api-keys [get]
+func ListAPIKeys(c *gin.Context) {
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	keys, err := services.NewAPIKeyService().ListAPIKeys(userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, keys)
+}
+
+// DeleteAPIKey revokes one of the authenticated user's API keys
+//
+//	@Summary	Revoke an API key
+//	@Tags		api-keys
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Param		id	path		int	true	"API key ID"
+//	@Success	200	{object}	MessageResponse
+//	@Failure	400	{object}	apperrors.Response
+//	@Failure	401	{object}	apperrors.Response
+//	@Failure	404	{object}	apperrors.Response
+//	@Failure	500	{object}	apperrors.Response
+//	@Router		/me/api-keys/{id} [delete]
+func DeleteAPIKey(c *gin.Context) {
+	keyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrBadRequest.WithMessage("Invalid API key ID"))
+		return
+	}
+
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	if err := services.NewAPIKeyService().RevokeAPIKey(uint(keyID), userID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, MessageResponse{
+		Message: "API key revoked successfully",
+	})
+}
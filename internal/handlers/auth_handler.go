@@ -1,14 +1,15 @@
 package handlers
 
 import (
-	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 
+	"task-manager/config"
+	"task-manager/internal/apperrors"
 	"task-manager/internal/models"
-	"task-manager/pkg/database"
-	"task-manager/pkg/utils"
+	"task-manager/internal/services"
 )
 
 // RegisterRequest represents the request body for user registration
@@ -20,126 +21,156 @@ type RegisterRequest struct {
 
 // LoginRequest represents the request body for user login
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	RememberMe bool   `json:"remember_me"`
 }
 
 // AuthResponse represents the response data for authentication operations
 type AuthResponse struct {
-	Token string      `json:"token"`
-	User  models.User `json:"user"`
+	Token     string           `json:"token"`
+	ExpiresAt models.Timestamp `json:"expires_at" swaggertype:"string" format:"date-time"`
+	User      models.User      `json:"user"`
+}
+
+// TokenIntrospectionResponse represents the decoded claims of a token.
+// The claims are omitted when the token's signature can't be verified.
+type TokenIntrospectionResponse struct {
+	// Active reports whether the token would be accepted right now
+	Active bool `json:"active"`
+	// Reason is the error code explaining why the token isn't active, e.g.
+	// token_expired
+	Reason    string            `json:"reason,omitempty"`
+	UserID    uint              `json:"user_id,omitempty"`
+	IssuedAt  *models.Timestamp `json:"issued_at,omitempty" swaggertype:"string" format:"date-time"`
+	ExpiresAt *models.Timestamp `json:"expires_at,omitempty" swaggertype:"string" format:"date-time"`
+	// ExpiresIn is how many seconds an active token has left
+	ExpiresIn int64 `json:"expires_in,omitempty"`
 }
 
 // Register handles user registration
+//
+//	@Summary	Register a new user
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		user	body		RegisterRequest	true	"Registration details"
+//	@Success	201		{object}	AuthResponse
+//	@Failure	400		{object}	apperrors.Response
+//	@Failure	403		{object}	apperrors.Response
+//	@Failure	409		{object}	apperrors.Response
+//	@Failure	422		{object}	apperrors.Response
+//	@Failure	500		{object}	apperrors.Response
+//	@Router		/auth/register [post]
 func Register(c *gin.Context) {
-	var req RegisterRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data: " + err.Error(),
-		})
+	// On invite-only instances accounts are created by admins
+	if !config.GetConfig().Security.RegistrationEnabled {
+		respondError(c, apperrors.ErrRegistrationDisabled)
 		return
 	}
 
-	// Check if username already exists
-	var existingUser models.User
-	result := database.GetDB().Where("username = ?", req.Username).First(&existingUser)
-	if result.Error == nil {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": "Username already exists",
-		})
-		return
-	} else if result.Error != gorm.ErrRecordNotFound {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database error: " + result.Error.Error(),
-		})
-		return
-	}
-
-	// Check if email already exists
-	result = database.GetDB().Where("email = ?", req.Email).First(&existingUser)
-	if result.Error == nil {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": "Email already exists",
-		})
-		return
-	} else if result.Error != gorm.ErrRecordNotFound {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database error: " + result.Error.Error(),
-		})
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, validationError(err))
 		return
 	}
 
-	// Create new user
-	user := models.User{
+	// Create the user; uniqueness checks and insert run in one transaction
+	authResp, err := services.NewUserService().Register(c.Request.Context(), services.UserRegisterRequest{
 		Username: req.Username,
 		Email:    req.Email,
 		Password: req.Password,
-	}
-
-	// Save user to database (password will be hashed by BeforeSave hook)
-	if err := database.GetDB().Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create user: " + err.Error(),
-		})
-		return
-	}
-
-	// Generate session ID (previously JWT token)
-	token, err := utils.GenerateToken(user.ID)
+		Client:   clientInfo(c),
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate session: " + err.Error(),
-		})
+		respondError(c, err)
 		return
 	}
 
 	// Return success response with token and user data
-	c.JSON(http.StatusCreated, AuthResponse{
-		Token: token,
-		User:  user,
+	respondCreated(c, AuthResponse{
+		Token:     authResp.Token,
+		ExpiresAt: models.NewTimestamp(authResp.ExpiresAt),
+		User:      *authResp.User,
 	})
 }
 
 // Login handles user authentication
+//
+//	@Summary	Log in
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		credentials	body		LoginRequest	true	"Login credentials"
+//	@Success	200			{object}	AuthResponse
+//	@Failure	400			{object}	apperrors.Response
+//	@Failure	401			{object}	apperrors.Response
+//	@Failure	422			{object}	apperrors.Response
+//	@Failure	429			{object}	apperrors.Response
+//	@Failure	500			{object}	apperrors.Response
+//	@Router		/auth/login [post]
 func Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data: " + err.Error(),
-		})
+		respondError(c, validationError(err))
 		return
 	}
 
-	// Find user by email
-	var user models.User
-	result := database.GetDB().Where("email = ?", req.Email).First(&user)
-	if result.Error != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid email or password",
-		})
+	// Verify the credentials, upgrading the password hash if needed
+	authResp, err := services.NewUserService().Login(c.Request.Context(), services.UserLoginRequest{
+		Email:      req.Email,
+		Password:   req.Password,
+		RememberMe: req.RememberMe,
+		Client:     clientInfo(c),
+	})
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Verify password
-	if err := user.CheckPassword(req.Password); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid email or password",
-		})
+	// Return success response with token and user data
+	respondOK(c, AuthResponse{
+		Token:     authResp.Token,
+		ExpiresAt: models.NewTimestamp(authResp.ExpiresAt),
+		User:      *authResp.User,
+	})
+}
+
+// IntrospectToken reports whether the bearer token is still accepted, with
+// its decoded claims
+//
+//	@Summary		Introspect a token
+//	@Description	Decodes the token in the Authorization header and reports whether it is active. An expired or otherwise rejected token is answered with active false and the reason rather than an error, so clients can tell when to log in again.
+//	@Tags			auth
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	TokenIntrospectionResponse
+//	@Failure		401	{object}	apperrors.Response
+//	@Failure		500	{object}	apperrors.Response
+//	@Router			/auth/introspect [get]
+func IntrospectToken(c *gin.Context) {
+	const bearerPrefix = "Bearer "
+	authHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, bearerPrefix) {
+		respondError(c, apperrors.ErrUnauthorized.WithMessage("Authorization header must be in format: Bearer {token}"))
 		return
 	}
 
-	// Generate session ID (previously JWT token)
-	token, err := utils.GenerateToken(user.ID)
+	result, err := services.NewUserService().IntrospectToken(c.Request.Context(), strings.TrimPrefix(authHeader, bearerPrefix))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate session: " + err.Error(),
-		})
+		respondError(c, err)
 		return
 	}
 
-	// Return success response with token and user data
-	c.JSON(http.StatusOK, AuthResponse{
-		Token: token,
-		User:  user,
-	})
-}
\ No newline at end of file
+	resp := TokenIntrospectionResponse{
+		Active: result.Active,
+		Reason: result.Reason,
+		UserID: result.UserID,
+	}
+	resp.IssuedAt = models.NewTimestampPtr(result.IssuedAt)
+	resp.ExpiresAt = models.NewTimestampPtr(result.ExpiresAt)
+	if result.ExpiresAt != nil && result.Active {
+		resp.ExpiresIn = int64(time.Until(*result.ExpiresAt).Seconds())
+	}
+	respondOK(c, resp)
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// computeETag returns a weak ETag for a resource identified by id that last
+// changed at updatedAt. variant distinguishes different representations of
+// the same resource, e.g. different field selections. The ETag is weak
+// because the gzip middleware may change the bytes sent.
+func computeETag(id uint, updatedAt time.Time, variant string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%d|%s", id, updatedAt.UnixNano(), variant)))
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag,
+// using the weak comparison RFC 9110 requires for If-None-Match
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"task-manager/internal/apperrors"
+	"task-manager/internal/middlewares"
+	"task-manager/internal/models"
+	"task-manager/internal/services"
+)
+
+// UserExportResponse represents everything stored about the authenticated
+// user
+type UserExportResponse struct {
+	ExportedAt    models.Timestamp          `json:"exported_at" swaggertype:"string" format:"date-time"`
+	User          models.User               `json:"user"`
+	Tasks         []models.Task             `json:"tasks"`
+	DeletedTasks  []models.Task             `json:"deleted_tasks"`
+	Activity      []models.TaskActivity     `json:"activity"`
+	Dependencies  []models.TaskDependency   `json:"dependencies"`
+	Collaborators []models.TaskCollaborator `json:"collaborators"`
+	APIKeys       []models.APIKey           `json:"api_keys"`
+	Sessions      []models.Session          `json:"sessions"`
+}
+
+// ExportData sends everything stored about the authenticated user as a
+// JSON file to download
+//
+//	@Summary		Export my data
+//	@Description	Returns the user's profile, tasks (including deleted ones not purged yet), their activity, dependencies and collaborators, API keys and sessions in one JSON document, as an attachment. Tasks of other users are left out even if shared with or assigned to the user. Password and API key hashes are never included.
+//	@Tags			users
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	UserExportResponse
+//	@Failure		401	{object}	apperrors.Response
+//	@Failure		500	{object}	apperrors.Response
+//	@Router			/me/export [get]
+func ExportData(c *gin.Context) {
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	export, err := services.NewUserService().ExportData(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	currentID, _ := middlewares.GetSessionID(c)
+	for i := range export.Sessions {
+		export.Sessions[i].Current = export.Sessions[i].ID == currentID
+	}
+
+	log.Printf("User %d exported their data", userID)
+
+	filename := fmt.Sprintf("task-manager-export-%s.json", export.ExportedAt.UTC().Format("20060102-150405"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	// Encode straight to the response rather than buffering the document
+	err = json.NewEncoder(c.Writer).Encode(UserExportResponse{
+		ExportedAt:    models.NewTimestamp(export.ExportedAt),
+		User:          *export.User,
+		Tasks:         export.Tasks,
+		DeletedTasks:  export.DeletedTasks,
+		Activity:      export.Activity,
+		Dependencies:  export.Dependencies,
+		Collaborators: export.Collaborators,
+		APIKeys:       export.APIKeys,
+		Sessions:      export.Sessions,
+	})
+	if err != nil {
+		// The status has been sent, so the download can only be cut short
+		_ = c.Error(err)
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"task-manager/internal/apperrors"
+)
+
+// NoRoute answers requests whose path matches no route
+func NoRoute(c *gin.Context) {
+	respondError(c, apperrors.ErrNotFound.WithMessage("No endpoint matches "+c.Request.URL.Path))
+}
+
+// NoMethod answers requests whose path matches a route but not for the
+// request method. By then gin has set the Allow header to the methods the
+// path supports. OPTIONS requests are asking for just that list, so they
+// get it without an error.
+func NoMethod(c *gin.Context) {
+	if c.Request.Method == http.MethodOptions {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	respondError(c, apperrors.ErrMethodNotAllowed.WithMessage(
+		"Method "+c.Request.Method+" is not allowed for "+c.Request.URL.Path))
+}
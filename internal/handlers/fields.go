@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"task-manager/internal/apperrors"
+	"task-manager/internal/services"
+)
+
+// taskFields lists the task fields clients may select with the fields query
+// parameter. Each JSON name is also the name of the task's column.
+var taskFields = map[string]bool{
+	"id":           true,
+	"user_id":      true,
+	"assignee_id":  true,
+	"title":        true,
+	"description":  true,
+	"due_date":     true,
+	"remind_at":    true,
+	"reminded_at":  true,
+	"priority":     true,
+	"status":       true,
+	"completed_at": true,
+	"color":        true,
+	"pinned":       true,
+	"archived_at":  true,
+	"position":     true,
+	"version":      true,
+	"created_at":   true,
+	"updated_at":   true,
+}
+
+// taskIncludes lists the relations clients may load along with tasks with
+// the include query parameter
+var taskIncludes = map[string]bool{
+	services.IncludeUser:     true,
+	services.IncludeAssignee: true,
+}
+
+// parseIncludes parses a comma-separated include query parameter, checking
+// each name against allowed. An empty raw value returns nil.
+func parseIncludes(raw string, allowed map[string]bool) ([]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var include []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || containsField(include, name) {
+			continue
+		}
+		if !allowed[name] {
+			return nil, apperrors.ErrValidation.WithFields(map[string]string{
+				"include": fmt.Sprintf("unknown relation %q", name),
+			})
+		}
+		include = append(include, name)
+	}
+	return include, nil
+}
+
+// parseFields parses a comma-separated fields query parameter, checking each
+// name against allowed. The result always starts with id; an empty raw
+// value returns nil, meaning all fields.
+func parseFields(raw string, allowed map[string]bool) ([]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	fields := []string{"id"}
+	seen := map[string]bool{"id": true}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if !allowed[name] {
+			return nil, apperrors.ErrValidation.WithFields(map[string]string{
+				"fields": fmt.Sprintf("unknown field %q", name),
+			})
+		}
+		seen[name] = true
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// containsField reports whether name is one of fields
+func containsField(fields []string, name string) bool {
+	for _, field := range fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// selectFields returns the JSON encoding of v reduced to the given fields
+func selectFields(v interface{}, fields []string) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, name := range fields {
+		if value, ok := all[name]; ok {
+			selected[name] = value
+		}
+	}
+	return selected, nil
+}
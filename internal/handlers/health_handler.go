@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"task-manager/internal/models"
+	"task-manager/pkg/database"
+	"task-manager/pkg/version"
+)
+
+// readinessTimeout bounds how long the readiness check waits for the database
+const readinessTimeout = 2 * time.Second
+
+// Ready reports whether the service can accept traffic by checking database
+// connectivity and that every migration this build expects has been applied,
+// so that a freshly deployed instance receives no traffic before the schema
+// is up to date. The response includes connection pool statistics to help
+// diagnose pool exhaustion.
+func Ready(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	var stats *database.PoolStats
+	err := database.Ping(ctx)
+	if err == nil {
+		stats, err = database.Stats()
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "unavailable",
+			"database": gin.H{
+				"status": "unreachable",
+			},
+		})
+		return
+	}
+
+	if err := models.VerifySchema(database.GetDB().WithContext(ctx)); err != nil {
+		_ = c.Error(err)
+		body := gin.H{"status": "schema_outdated"}
+		var pending *models.PendingMigrationsError
+		if errors.As(err, &pending) {
+			body["pending_migrations"] = pending.IDs
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":   "unavailable",
+			"database": body,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ready",
+		"database": gin.H{
+			"status": "ok",
+			"pool":   stats,
+		},
+	})
+}
+
+// Version reports which build is running, the Go runtime version and the
+// process uptime
+func Version(c *gin.Context) {
+	c.JSON(http.StatusOK, version.Get(time.Now()))
+}
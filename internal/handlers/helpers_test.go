@@ -0,0 +1,99 @@
+package handlers_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"task-manager/internal/apitest"
+	"task-manager/internal/models"
+	"task-manager/pkg/database"
+)
+
+// taskBody is the part of a task response the tests look at. models.Task
+// can't be decoded back, as its timestamps only marshal.
+type taskBody struct {
+	ID         uint    `json:"id"`
+	UserID     uint    `json:"user_id"`
+	Title      string  `json:"title"`
+	DueDate    *string `json:"due_date"`
+	Priority   string  `json:"priority"`
+	Status     string  `json:"status"`
+	Version    int     `json:"version"`
+	AssigneeID *uint   `json:"assignee_id"`
+}
+
+// errorBody is the body of an error response
+type errorBody struct {
+	Error struct {
+		Code string `json:"code"`
+	} `json:"error"`
+}
+
+// newHarness returns a harness on a fresh database, closed when the test ends
+func newHarness(t *testing.T) *apitest.Harness {
+	t.Helper()
+	h, err := apitest.New()
+	if err != nil {
+		t.Fatalf("failed to set up API: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := database.Reset(); err != nil {
+			t.Errorf("failed to close database: %v", err)
+		}
+	})
+	return h
+}
+
+// seedUser creates a user and returns it with a token for it
+func seedUser(t *testing.T, h *apitest.Harness, username string) (*models.User, string) {
+	t.Helper()
+	user, err := h.SeedUser(username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := h.Token(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user, token
+}
+
+// seedTask creates a task owned by the user
+func seedTask(t *testing.T, h *apitest.Harness, userID uint, title string) *models.Task {
+	t.Helper()
+	task, err := h.SeedTask(userID, title)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+// do sends a request and fails the test unless the response has the wanted
+// status
+func do(t *testing.T, h *apitest.Harness, method, path, token string, body interface{}, want int) *httptest.ResponseRecorder {
+	t.Helper()
+	w, err := h.Do(method, path, token, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != want {
+		t.Fatalf("%s %s: got status %d, want %d: %s", method, path, w.Code, want, w.Body.String())
+	}
+	return w
+}
+
+// decode decodes the response body into v
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := apitest.Decode(w, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// errorCode returns the code of an error response
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body errorBody
+	decode(t, w, &body)
+	return body.Error.Code
+}
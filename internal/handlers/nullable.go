@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// NullableTime is an optional timestamp in a partial update that tells an
+// absent key (Set is false) apart from an explicit null (Set is true and
+// Time is nil)
+type NullableTime struct {
+	Set  bool
+	Time *time.Time
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It is only called for keys
+// present in the body, so Set records that the client sent the field.
+func (t *NullableTime) UnmarshalJSON(data []byte) error {
+	t.Set = true
+	if bytes.Equal(data, []byte("null")) {
+		t.Time = nil
+		return nil
+	}
+
+	var value time.Time
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	t.Time = &value
+	return nil
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newPaginationMeta builds the pagination metadata for a list response,
+// including links to the neighbouring pages, and sets the matching Link
+// header (RFC 8288). page is the page served, which the services clamp to
+// the last page.
+func newPaginationMeta(c *gin.Context, page, pageSize int, totalItems, totalPages int64) PaginationMeta {
+	requested, _ := strconv.Atoi(c.Query("page"))
+	meta := PaginationMeta{
+		CurrentPage: page,
+		PageSize:    pageSize,
+		TotalItems:  totalItems,
+		TotalPages:  totalPages,
+		PageClamped: requested > page,
+	}
+
+	var links []string
+	if int64(page) < totalPages {
+		next := pageURL(c, page+1)
+		meta.NextPageURL = &next
+		links = append(links, `<`+next+`>; rel="next"`)
+	}
+	if page > 1 {
+		prev := pageURL(c, page-1)
+		meta.PrevPageURL = &prev
+		links = append(links, `<`+prev+`>; rel="prev"`)
+	}
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+
+	return meta
+}
+
+// pageURL returns the current request's path and query with the page
+// parameter replaced, keeping any filter and sort parameters
+func pageURL(c *gin.Context, page int) string {
+	query := c.Request.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	return c.Request.URL.Path + "?" + query.Encode()
+}
@@ -0,0 +1,84 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"task-manager/config"
+	"task-manager/internal/handlers"
+	"task-manager/internal/services"
+)
+
+// TestTaskListPaginationParity checks that GET /tasks pages the way
+// TaskService.GetTasks does, as the handler only delegates to it
+func TestTaskListPaginationParity(t *testing.T) {
+	h := newHarness(t)
+	user, token := seedUser(t, h, "alice")
+	for i := 0; i < 25; i++ {
+		seedTask(t, h, user.ID, fmt.Sprintf("Task %d", i+1))
+	}
+	maxPageSize := config.GetConfig().Pagination.MaxPageSize
+
+	// list returns the pagination of the handler's response to query
+	list := func(t *testing.T, query string) handlers.PaginationMeta {
+		t.Helper()
+		w := do(t, h, http.MethodGet, "/api/v1/tasks/"+query, token, nil, http.StatusOK)
+		var body struct {
+			Pagination handlers.PaginationMeta `json:"pagination"`
+		}
+		decode(t, w, &body)
+		return body.Pagination
+	}
+
+	// service returns the service's result for page and pageSize
+	service := func(t *testing.T, page, pageSize int) *services.PaginatedTasksResponse {
+		t.Helper()
+		result, err := services.NewTaskService().GetTasks(context.Background(), services.TaskFilterOptions{
+			UserID:   user.ID,
+			Page:     page,
+			PageSize: pageSize,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	// same fails the test if the handler and the service paged differently
+	same := func(t *testing.T, got handlers.PaginationMeta, want *services.PaginatedTasksResponse) {
+		t.Helper()
+		if got.CurrentPage != want.CurrentPage || got.PageSize != want.PageSize ||
+			got.TotalItems != want.TotalItems || got.TotalPages != want.TotalPages {
+			t.Errorf("handler paged as %+v, service as page %d of %d, size %d, %d items",
+				got, want.CurrentPage, want.TotalPages, want.PageSize, want.TotalItems)
+		}
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		got := list(t, "")
+		same(t, got, service(t, 0, 0))
+		if got.PageSize != config.GetConfig().Pagination.DefaultPageSize {
+			t.Errorf("got page size %d, want the configured default", got.PageSize)
+		}
+	})
+
+	t.Run("max page size", func(t *testing.T) {
+		same(t, list(t, fmt.Sprintf("?page_size=%d", maxPageSize)), service(t, 1, maxPageSize))
+
+		// The handler refuses what the service would clamp, with the same bound
+		do(t, h, http.MethodGet, fmt.Sprintf("/api/v1/tasks/?page_size=%d", maxPageSize+1), token, nil, http.StatusBadRequest)
+		if clamped := service(t, 1, maxPageSize+1); clamped.PageSize != maxPageSize {
+			t.Errorf("service used page size %d, want it clamped to %d", clamped.PageSize, maxPageSize)
+		}
+	})
+
+	t.Run("page out of range", func(t *testing.T) {
+		got := list(t, "?page=99&page_size=10")
+		same(t, got, service(t, 99, 10))
+		if got.CurrentPage != 3 || !got.PageClamped {
+			t.Errorf("got page %d, clamped %t; want the last page, 3, clamped", got.CurrentPage, got.PageClamped)
+		}
+	})
+}
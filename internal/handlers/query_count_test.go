@@ -0,0 +1,56 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"task-manager/pkg/database"
+)
+
+// listTasksQueries is how many statements listing tasks with their owners and
+// assignees takes: loading the authenticated user, counting the tasks,
+// loading a page of them, and loading their owners and their assignees
+const listTasksQueries = 5
+
+func TestListTasksQueryCount(t *testing.T) {
+	h := newHarness(t)
+	owner, token := seedUser(t, h, "alice")
+	assignee, _ := seedUser(t, h, "bob")
+
+	// queries returns how many statements listing the tasks took
+	queries := func(t *testing.T) int64 {
+		t.Helper()
+		ctx, counter := database.WithQueryCounter(context.Background())
+		w, err := h.DoContext(ctx, http.MethodGet, "/api/v1/tasks/?include=user,assignee&page_size=100", token, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d: %s", w.Code, w.Body.String())
+		}
+		return counter.Count()
+	}
+
+	// seed adds n tasks assigned to assignee
+	seed := func(t *testing.T, n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			task := seedTask(t, h, owner.ID, "Task")
+			if err := h.DB.Model(task).Update("assignee_id", assignee.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	seed(t, 2)
+	if got := queries(t); got != listTasksQueries {
+		t.Errorf("listing 2 tasks took %d queries, want %d", got, listTasksQueries)
+	}
+
+	// The count must not grow with the number of tasks
+	seed(t, 20)
+	if got := queries(t); got != listTasksQueries {
+		t.Errorf("listing 22 tasks took %d queries, want %d", got, listTasksQueries)
+	}
+}
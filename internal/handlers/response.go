@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"task-manager/internal/apperrors"
+)
+
+// EnvelopeMediaType is the media type a client lists in its Accept header to
+// have success responses wrapped in an Envelope. Other clients get the bare
+// response bodies.
+const EnvelopeMediaType = "application/vnd.task-manager.envelope+json"
+
+// Envelope is the uniform shape of success responses for clients that ask
+// for it: the resource, or the items of a list, in data and list metadata
+// such as pagination in meta
+type Envelope struct {
+	Data interface{} `json:"data"`
+	Meta interface{} `json:"meta,omitempty"`
+}
+
+// ListMeta is the meta of an enveloped list response
+type ListMeta struct {
+	Pagination PaginationMeta `json:"pagination"`
+	// Highlights and Scores are only set for task searches that ask for them
+	Highlights map[uint]TaskHighlight `json:"highlights,omitempty"`
+	Scores     map[uint]float64       `json:"scores,omitempty"`
+}
+
+// enveloper is implemented by response bodies that don't go into an
+// Envelope's data as a whole, such as lists with pagination
+type enveloper interface {
+	envelope() Envelope
+}
+
+// MessageResponse represents a response carrying only a message
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// respondOK writes body as a 200 JSON response, see respond
+func respondOK(c *gin.Context, body interface{}) {
+	respond(c, http.StatusOK, body)
+}
+
+// respondCreated writes body as a 201 JSON response, see respond
+func respondCreated(c *gin.Context, body interface{}) {
+	respond(c, http.StatusCreated, body)
+}
+
+// respond writes body as a JSON response with the given status, wrapped in
+// an Envelope if the client asked for one
+func respond(c *gin.Context, status int, body interface{}) {
+	c.Writer.Header().Add("Vary", "Accept")
+
+	if !wantsEnvelope(c) {
+		c.JSON(status, body)
+		return
+	}
+	if e, ok := body.(enveloper); ok {
+		c.JSON(status, e.envelope())
+		return
+	}
+	c.JSON(status, Envelope{Data: body})
+}
+
+// wantsEnvelope reports whether the request's Accept header lists
+// EnvelopeMediaType
+func wantsEnvelope(c *gin.Context) bool {
+	for _, accepted := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && mediaType == EnvelopeMediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// respondError writes err as a JSON error response of the form
+// {"error": {"code": ..., "message": ...}}. Errors that are not
+// *apperrors.AppError are reported as internal errors.
+func respondError(c *gin.Context, err error) {
+	appErr := apperrors.From(err)
+
+	// Record server-side failures so the logger middleware includes the cause
+	if appErr.Status >= 500 {
+		_ = c.Error(err)
+	}
+
+	c.JSON(appErr.Status, appErr.Response())
+}
+
+func (r TaskListResponse) envelope() Envelope {
+	return Envelope{Data: r.Tasks, Meta: ListMeta{Pagination: r.Pagination, Highlights: r.Highlights, Scores: r.Scores}}
+}
+
+func (r partialTaskListResponse) envelope() Envelope {
+	return Envelope{Data: r.Tasks, Meta: ListMeta{Pagination: r.Pagination, Highlights: r.Highlights, Scores: r.Scores}}
+}
+
+func (r TaskActivityListResponse) envelope() Envelope {
+	return Envelope{Data: r.Activities, Meta: ListMeta{Pagination: r.Pagination}}
+}
+
+func (r BoardResponse) envelope() Envelope {
+	return Envelope{Data: r.Columns}
+}
+
+func (r ReorderTasksResponse) envelope() Envelope {
+	return Envelope{Data: r.Tasks}
+}
+
+func (r TaskDependenciesResponse) envelope() Envelope {
+	return Envelope{Data: r.Dependencies}
+}
+
+func (r UserListResponse) envelope() Envelope {
+	return Envelope{Data: r.Users, Meta: ListMeta{Pagination: r.Pagination}}
+}
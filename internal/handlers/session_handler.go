@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"task-manager/internal/apperrors"
+	"task-manager/internal/middlewares"
+	"task-manager/internal/services"
+)
+
+// ListSessions lists the authenticated user's active login sessions
+//
+//	@Summary		List sessions
+//	@Description	Lists where the user is logged in: a session per login that hasn't expired or been revoked, most recent first. The session of the token used for the request is marked current.
+//	@Tags			sessions
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Success		200	{array}		models.Session
+//	@Failure		401	{object}	apperrors.Response
+//	@Failure		500	{object}	apperrors.Response
+//	@Router			/me/sessions [get]
+func ListSessions(c *gin.Context) {
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+	currentID, _ := middlewares.GetSessionID(c)
+
+	sessions, err := services.NewSessionService().ListSessions(c.Request.Context(), userID, currentID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, sessions)
+}
+
+// DeleteSession revokes one of the authenticated user's login sessions
+//
+//	@Summary		Revoke a session
+//	@Description	Logs the session out: its token is refused from then on. Revoking the current session logs out the caller.
+//	@Tags			sessions
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"Session ID"
+//	@Success		200	{object}	MessageResponse
+//	@Failure		400	{object}	apperrors.Response
+//	@Failure		401	{object}	apperrors.Response
+//	@Failure		404	{object}	apperrors.Response
+//	@Failure		500	{object}	apperrors.Response
+//	@Router			/me/sessions/{id} [delete]
+func DeleteSession(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrBadRequest.WithMessage("Invalid session ID"))
+		return
+	}
+
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	if err := services.NewSessionService().RevokeSession(c.Request.Context(), uint(sessionID), userID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, MessageResponse{
+		Message: "Session revoked successfully",
+	})
+}
+
+// clientInfo describes the client making the request, for recording in the
+// session a login starts
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"task-manager/internal/apperrors"
+	"task-manager/internal/middlewares"
+	"task-manager/internal/models"
+	"task-manager/internal/services"
+)
+
+// taskEventsKeepAlive is how often a comment is sent on an idle event
+// stream so proxies don't close it
+const taskEventsKeepAlive = 15 * time.Second
+
+// StreamTaskEvents streams the user's task changes as server-sent events
+//
+//	@Summary		Stream task events
+//	@Description	Server-sent event stream of the user's task changes. Each event is named after its type (created, updated, deleted or bulk_deleted) and carries a JSON TaskEvent. Since EventSource can't set headers, the JWT may be passed in the access_token query parameter.
+//	@Tags			tasks
+//	@Produce		text/event-stream
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			access_token	query		string	false	"JWT, for clients that can't send the Authorization header"
+//	@Success		200				{object}	services.TaskEvent
+//	@Failure		401				{object}	apperrors.Response
+//	@Router			/tasks/events [get]
+func StreamTaskEvents(c *gin.Context) {
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	events, unsubscribe := services.TaskEvents().Subscribe(userID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(taskEventsKeepAlive)
+	defer keepAlive.Stop()
+
+	// Stream until the client disconnects or the server shuts down
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			c.SSEvent(string(event.Type), event)
+		case <-keepAlive.C:
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// publishTaskEvent notifies the owner's event streams of a task change
+func publishTaskEvent(userID uint, eventType services.TaskEventType, task *models.Task) {
+	services.TaskEvents().Publish(userID, services.TaskEvent{
+		Type:   eventType,
+		TaskID: task.ID,
+		Task:   task,
+	})
+}
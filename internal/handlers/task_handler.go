@@ -1,24 +1,58 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 
+	"task-manager/config"
+	"task-manager/internal/apperrors"
 	"task-manager/internal/middlewares"
 	"task-manager/internal/models"
-	"task-manager/pkg/database"
+	"task-manager/internal/services"
 )
 
 // TaskRequest represents the request body for creating/updating a task
 type TaskRequest struct {
-	Title       string        `json:"title" binding:"required,max=200"`
-	Description string        `json:"description"`
-	DueDate     *time.Time    `json:"due_date"`
+	Title       string          `json:"title" binding:"required,max=200"`
+	Description string          `json:"description"`
+	DueDate     *time.Time      `json:"due_date"`
+	RemindAt    *time.Time      `json:"remind_at"`
 	Priority    models.Priority `json:"priority" binding:"omitempty,oneof=low medium high"`
+	// Color is a #RRGGBB color used to tell tasks apart, or empty for none
+	Color string `json:"color"`
+	// Version is the version of the task being updated, as last read by the
+	// client; it is required on update, except under the unversioned /api,
+	// and ignored on create
+	Version int `json:"version" binding:"omitempty,min=1"`
+}
+
+// TaskPatchRequest represents the request body for partially updating a
+// task. Absent fields are left unchanged; due_date and remind_at are
+// cleared by an explicit null.
+type TaskPatchRequest struct {
+	Title       *string          `json:"title" binding:"omitempty,min=1,max=200"`
+	Description *string          `json:"description"`
+	DueDate     NullableTime     `json:"due_date" swaggertype:"string" format:"date-time"`
+	RemindAt    NullableTime     `json:"remind_at" swaggertype:"string" format:"date-time"`
+	Priority    *models.Priority `json:"priority" binding:"omitempty,oneof=low medium high"`
+	// Color is a #RRGGBB color; an empty string removes the task's color
+	Color *string `json:"color"`
+	// Version is the version of the task being updated, as last read by the
+	// client; it is required, except under the unversioned /api
+	Version int `json:"version" binding:"omitempty,min=1"`
+}
+
+// AssignTaskRequest represents the request body for assigning a task
+type AssignTaskRequest struct {
+	Email string `json:"email" binding:"required,email"`
 }
 
 // TaskStatusRequest represents the request body for updating task status
@@ -26,374 +60,1823 @@ type TaskStatusRequest struct {
 	Status models.Status `json:"status" binding:"required,oneof=todo in_progress completed"`
 }
 
+// SnoozeTaskRequest represents the request body for snoozing a task. Exactly
+// one of Duration and Preset must be given.
+type SnoozeTaskRequest struct {
+	// Duration is how long from now the task becomes due, e.g. "2h"
+	Duration string `json:"duration"`
+	// Preset makes the task due at the current time of day tomorrow or in a
+	// week
+	Preset string `json:"preset" binding:"omitempty,oneof=tomorrow next_week"`
+}
+
+// PinTaskRequest represents the request body for pinning or unpinning a task
+type PinTaskRequest struct {
+	Pinned *bool `json:"pinned" binding:"required"`
+}
+
 // PaginationQuery represents the query parameters for pagination
 type PaginationQuery struct {
 	Page     int `form:"page" binding:"omitempty,min=1"`
-	PageSize int `form:"page_size" binding:"omitempty,min=5,max=100"`
+	PageSize int `form:"page_size" binding:"omitempty,min=5,max_page_size"`
 }
 
 // TaskFilterQuery represents the query parameters for filtering tasks
 type TaskFilterQuery struct {
 	Status   string `form:"status" binding:"omitempty,oneof=todo in_progress completed"`
 	Priority string `form:"priority" binding:"omitempty,oneof=low medium high"`
-	SortBy   string `form:"sort_by" binding:"omitempty,oneof=created_at due_date priority title"`
+	SortBy   string `form:"sort_by" binding:"omitempty,oneof=created_at due_date priority title position"`
 	Order    string `form:"order" binding:"omitempty,oneof=asc desc"`
+	// AssignedToMe includes tasks assigned to the user as well as their own
+	AssignedToMe bool `form:"assigned_to_me"`
+	// SharedWithMe includes tasks shared with the user as well as their own
+	SharedWithMe bool `form:"shared_with_me"`
+	// HasDueDate limits the tasks to those with or without a due date
+	HasDueDate *bool `form:"has_due_date"`
+	// CreatedAfter and CreatedBefore limit the tasks to those created in
+	// [CreatedAfter, CreatedBefore), given as RFC 3339 timestamps
+	CreatedAfter  *time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	// Pinned limits the tasks to pinned or unpinned ones
+	Pinned *bool `form:"pinned"`
+	// PinnedFirst lists pinned tasks before the others
+	PinnedFirst bool `form:"pinned_first"`
+	// IncludeArchived also lists archived tasks
+	IncludeArchived bool `form:"include_archived"`
+	// Fields is a comma-separated list of the task fields to return
+	Fields string `form:"fields"`
+	// Include is a comma-separated list of the relations to embed
+	Include string `form:"include"`
+	// Search matches tasks whose title or description contains it
+	Search string `form:"search" binding:"omitempty,max=100"`
+	// SearchMode selects substring (default) or fulltext search
+	SearchMode string `form:"search_mode" binding:"omitempty,oneof=substring fulltext"`
+	// Highlight returns snippets of the search matches
+	Highlight bool `form:"highlight"`
+}
+
+// TaskCountQuery represents the query parameters for counting tasks
+type TaskCountQuery struct {
+	Status   string `form:"status" binding:"omitempty,oneof=todo in_progress completed"`
+	Priority string `form:"priority" binding:"omitempty,oneof=low medium high"`
+	// AssignedToMe includes tasks assigned to the user as well as their own
+	AssignedToMe bool `form:"assigned_to_me"`
+	// SharedWithMe includes tasks shared with the user as well as their own
+	SharedWithMe bool `form:"shared_with_me"`
+	// IncludeArchived also counts archived tasks
+	IncludeArchived bool `form:"include_archived"`
+}
+
+// BoardQuery represents the query parameters for the task board
+type BoardQuery struct {
+	// LimitPerColumn is how many tasks each column holds at most
+	LimitPerColumn int `form:"limit_per_column" binding:"omitempty,min=1,max_page_size"`
+}
+
+// UpcomingTasksQuery represents the query parameters for listing upcoming tasks
+type UpcomingTasksQuery struct {
+	// Days is how far ahead to look; values above maxUpcomingDays are capped
+	Days int `form:"days" binding:"omitempty,min=1"`
+}
+
+// Look-ahead window of the upcoming tasks endpoint, in days
+const (
+	defaultUpcomingDays = 7
+	maxUpcomingDays     = 365
+)
+
+// TaskBatchQuery represents the query parameters for fetching tasks by ID
+type TaskBatchQuery struct {
+	// IDs is a comma-separated list of task IDs
+	IDs string `form:"ids" binding:"required"`
+}
+
+// maxBatchTaskIDs is how many tasks can be fetched by ID in one request
+const maxBatchTaskIDs = 100
+
+// CompletedReportQuery represents the query parameters for the completed
+// tasks report. Both days are included and interpreted in the configured
+// time zone.
+type CompletedReportQuery struct {
+	From string `form:"from" binding:"required,datetime=2006-01-02"`
+	To   string `form:"to" binding:"required,datetime=2006-01-02"`
+}
+
+// maxCompletedReportDays is the longest range of the completed tasks report
+const maxCompletedReportDays = 366
+
+// DeleteTaskQuery represents the query parameters for deleting a task
+type DeleteTaskQuery struct {
+	Permanent bool `form:"permanent"`
+	// Confirm must repeat the task ID for a permanent delete
+	Confirm string `form:"confirm"`
+}
+
+// DeleteTasksQuery represents the query parameters for deleting tasks by filter
+type DeleteTasksQuery struct {
+	Status   string `form:"status" binding:"omitempty,oneof=todo in_progress completed"`
+	Priority string `form:"priority" binding:"omitempty,oneof=low medium high"`
+	DryRun   bool   `form:"dry_run"`
+}
+
+// DeleteTasksResponse represents the response body for deleting tasks by filter
+type DeleteTasksResponse struct {
+	Deleted int64 `json:"deleted"`
+	DryRun  bool  `json:"dry_run"`
+}
+
+// TaskListResponse represents the response body for a paginated list of tasks
+type TaskListResponse struct {
+	Tasks []models.Task `json:"tasks"`
+	// Highlights holds search snippets keyed by task ID, if requested
+	Highlights map[uint]TaskHighlight `json:"highlights,omitempty"`
+	// Scores holds the relevance of each task keyed by task ID, in fulltext
+	// search mode
+	Scores     map[uint]float64 `json:"scores,omitempty"`
+	Pagination PaginationMeta   `json:"pagination"`
+}
+
+// partialTaskListResponse is a TaskListResponse whose tasks were reduced to
+// the fields the client selected
+type partialTaskListResponse struct {
+	Tasks      []map[string]json.RawMessage `json:"tasks"`
+	Highlights map[uint]TaskHighlight       `json:"highlights,omitempty"`
+	Scores     map[uint]float64             `json:"scores,omitempty"`
+	Pagination PaginationMeta               `json:"pagination"`
+}
+
+// TaskCreatedResponse represents a newly created task. Warnings point out
+// possible mistakes that didn't stop the task from being created.
+type TaskCreatedResponse struct {
+	models.Task
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// MarshalJSON adds the warnings to the task's own JSON, which would otherwise
+// replace the response's because models.Task has a MarshalJSON
+func (r TaskCreatedResponse) MarshalJSON() ([]byte, error) {
+	task, err := json.Marshal(r.Task)
+	if err != nil || len(r.Warnings) == 0 {
+		return task, err
+	}
+	warnings, err := json.Marshal(r.Warnings)
+	if err != nil {
+		return nil, err
+	}
+
+	out := append(task[:len(task)-1], `,"warnings":`...)
+	out = append(out, warnings...)
+	return append(out, '}'), nil
+}
+
+// TaskHighlight holds HTML-escaped snippets of a task's title and
+// description with each search match wrapped in <mark></mark>. A field is
+// omitted if the search term doesn't occur in it.
+type TaskHighlight struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// TaskActivityListResponse represents the response body for a paginated
+// task activity log
+type TaskActivityListResponse struct {
+	Activities []models.TaskActivity `json:"activities"`
+	Pagination PaginationMeta        `json:"pagination"`
+}
+
+// ReorderTasksRequest represents the request body for reordering tasks
+type ReorderTasksRequest struct {
+	// TaskIDs lists the tasks in their new order
+	TaskIDs []uint `json:"task_ids" binding:"required,min=1,max=500,dive,required"`
+}
+
+// ReorderTasksResponse represents the response body listing the reordered
+// tasks in their new order
+type ReorderTasksResponse struct {
+	Tasks []models.Task `json:"tasks"`
+}
+
+// BoardColumnResponse represents one status column of the task board
+type BoardColumnResponse struct {
+	Status models.Status `json:"status"`
+	// Tasks are the first tasks of the column in manual order
+	Tasks []models.Task `json:"tasks"`
+	// Total is how many tasks have the status, including those not returned
+	Total int64 `json:"total"`
+}
+
+// BoardResponse represents the response body for the task board
+type BoardResponse struct {
+	Columns []BoardColumnResponse `json:"columns"`
+}
+
+// TaskBatchResponse represents the response body for fetching tasks by ID
+type TaskBatchResponse struct {
+	// Tasks are in the order their IDs were given
+	Tasks []models.Task `json:"tasks"`
+	// Missing lists the IDs of tasks that don't exist or aren't the user's
+	Missing []uint `json:"missing"`
+}
+
+// CompletedDayResponse represents the tasks completed on one day
+type CompletedDayResponse struct {
+	Date  string        `json:"date"`
+	Count int           `json:"count"`
+	Tasks []models.Task `json:"tasks"`
+}
+
+// CompletedReportResponse represents the response body for the completed
+// tasks report
+type CompletedReportResponse struct {
+	// Days covers every day of the range, oldest first
+	Days []CompletedDayResponse `json:"days"`
+	// Total is how many tasks were completed in the range
+	Total int `json:"total"`
+}
+
+// StreakResponse represents the response body for the user's completion
+// streaks
+type StreakResponse struct {
+	// CurrentStreak is the number of consecutive days up to today (or
+	// yesterday) with at least one completed task
+	CurrentStreak int `json:"current_streak"`
+	// LongestStreak is the longest such run so far
+	LongestStreak  int `json:"longest_streak"`
+	TotalCompleted int `json:"total_completed"`
+}
+
+// ShareTaskRequest represents the request body for sharing a task
+type ShareTaskRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=viewer editor"`
+}
+
+// TaskCollaboratorsResponse represents the users a task is shared with
+type TaskCollaboratorsResponse struct {
+	Collaborators []models.TaskCollaborator `json:"collaborators"`
+}
+
+// TaskDependencyRequest represents the request body for adding a task dependency
+type TaskDependencyRequest struct {
+	// DependsOnID is the task that has to be completed first
+	DependsOnID uint `json:"depends_on_id" binding:"required"`
+}
+
+// TaskDependenciesResponse represents the response body listing the tasks
+// blocking a task
+type TaskDependenciesResponse struct {
+	Dependencies []models.Task `json:"dependencies"`
+}
+
+// TaskValidationResponse represents the response body for a task that
+// passed validation
+type TaskValidationResponse struct {
+	Valid bool `json:"valid"`
+}
+
+// TaskCountResponse represents the response body for a task count
+type TaskCountResponse struct {
+	Count int64 `json:"count"`
+}
+
+// PaginationMeta represents the pagination metadata of a list response.
+// The page URLs are nil on the first and last pages. PageClamped is set when
+// the requested page was past the last page and the last page was returned
+// instead.
+type PaginationMeta struct {
+	CurrentPage int     `json:"current_page"`
+	PageSize    int     `json:"page_size"`
+	TotalItems  int64   `json:"total_items"`
+	TotalPages  int64   `json:"total_pages"`
+	PageClamped bool    `json:"page_clamped"`
+	NextPageURL *string `json:"next_page_url"`
+	PrevPageURL *string `json:"prev_page_url"`
 }
 
 // CreateTask handles the creation of a new task
+//
+//	@Summary	Create a task
+//	@Tags		tasks
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Param		task			body		TaskRequest	true	"Task to create"
+//	@Param		Idempotency-Key	header		string		false	"Key that makes retries return the original task"
+//	@Success	201				{object}	TaskCreatedResponse
+//	@Failure	400				{object}	apperrors.Response
+//	@Failure	401				{object}	apperrors.Response
+//	@Failure	403				{object}	apperrors.Response
+//	@Failure	422				{object}	apperrors.Response
+//	@Failure	500				{object}	apperrors.Response
+//	@Router		/tasks [post]
 func CreateTask(c *gin.Context) {
-	var req TaskRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data: " + err.Error(),
-		})
+	taskReq, err := bindNewTask(c)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Get user ID from context (set by auth middleware)
-	userID, exists := middlewares.GetUserID(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Unauthorized",
-		})
+	// Retries carrying the same Idempotency-Key get the original task back
+	var (
+		task     *models.Task
+		replayed bool
+	)
+	if idempotencyKey := c.GetHeader("Idempotency-Key"); idempotencyKey != "" {
+		if len(idempotencyKey) > 255 {
+			respondError(c, apperrors.ErrBadRequest.WithMessage("Idempotency-Key must be at most 255 characters"))
+			return
+		}
+		task, replayed, err = services.NewTaskService().CreateTaskIdempotent(c.Request.Context(), taskReq, idempotencyKey)
+	} else {
+		task, err = services.NewTaskService().CreateTask(c.Request.Context(), taskReq)
+	}
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Create new task
-	task := models.Task{
-		UserID:      userID,
-		Title:       req.Title,
-		Description: req.Description,
-		DueDate:     req.DueDate,
-		Status:      models.StatusTodo, // Default status is todo
+	if replayed {
+		c.Header("Idempotent-Replayed", "true")
+	} else {
+		publishTaskEvent(taskReq.UserID, services.TaskCreated, task)
 	}
 
-	// Set priority if provided, otherwise use default (medium)
-	if req.Priority != "" {
-		task.Priority = req.Priority
-	} else {
-		task.Priority = models.PriorityMedium
+	respondCreated(c, TaskCreatedResponse{
+		Task:     *task,
+		Warnings: taskWarnings(c.Request.Context(), task),
+	})
+}
+
+// taskWarnings returns the warnings about a newly created task. They are
+// advisory, so failing to work them out doesn't fail the request.
+func taskWarnings(ctx context.Context, task *models.Task) []string {
+	if !config.GetConfig().Tasks.WarnOnDuplicateTitle {
+		return nil
 	}
 
-	// Save task to database
-	if err := database.GetDB().Create(&task).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create task: " + err.Error(),
-		})
+	duplicate, err := services.NewTaskService().FindDuplicateTitle(ctx, task)
+	if err != nil {
+		log.Printf("Failed to look for duplicates of task %d: %v", task.ID, err)
+		return nil
+	}
+	if duplicate == nil {
+		return nil
+	}
+	return []string{fmt.Sprintf("possible duplicate of task #%d", duplicate.ID)}
+}
+
+// ValidateTask checks a task as CreateTask would without creating it
+//
+//	@Summary		Validate a new task
+//	@Description	Runs the same checks as creating the task, without saving anything, so forms can be validated before they are submitted
+//	@Tags			tasks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			task	body		TaskRequest	true	"Task to validate"
+//	@Success		200		{object}	TaskValidationResponse
+//	@Failure		400		{object}	apperrors.Response
+//	@Failure		401		{object}	apperrors.Response
+//	@Failure		422		{object}	apperrors.Response
+//	@Failure		500		{object}	apperrors.Response
+//	@Router			/tasks/validate [post]
+func ValidateTask(c *gin.Context) {
+	taskReq, err := bindNewTask(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := services.NewTaskService().ValidateTask(taskReq); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, task)
+	respondOK(c, TaskValidationResponse{Valid: true})
+}
+
+// bindNewTask binds and checks the body of a request creating a task for the
+// authenticated user. Checks of the body belong here and business rules in
+// TaskService.ValidateTask, so that ValidateTask applies both.
+func bindNewTask(c *gin.Context) (services.TaskRequest, error) {
+	var req TaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return services.TaskRequest{}, validationError(err)
+	}
+
+	// Get user ID from context (set by auth middleware)
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		return services.TaskRequest{}, apperrors.ErrUnauthorized
+	}
+
+	return services.TaskRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		DueDate:     req.DueDate,
+		RemindAt:    req.RemindAt,
+		Priority:    req.Priority,
+		Color:       req.Color,
+		UserID:      userID,
+	}, nil
 }
 
 // GetTask retrieves a single task by its ID
+//
+//	@Summary	Get a task
+//	@Tags		tasks
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Param		id				path		int		true	"Task ID"
+//	@Param		fields			query		string	false	"Comma-separated fields to return, e.g. id,title,status (id is always included)"
+//	@Param		include			query		string	false	"Comma-separated relations to embed: user (the owner), assignee"
+//	@Param		If-None-Match	header		string	false	"ETag of the client's copy; returns 304 if unchanged"
+//	@Success	200				{object}	models.Task
+//	@Header		200				{string}	ETag	"Version of the returned representation"
+//	@Success	304				"Not Modified"
+//	@Failure	400				{object}	apperrors.Response
+//	@Failure	401				{object}	apperrors.Response
+//	@Failure	404				{object}	apperrors.Response
+//	@Failure	422				{object}	apperrors.Response
+//	@Failure	500				{object}	apperrors.Response
+//	@Router		/tasks/{id} [get]
 func GetTask(c *gin.Context) {
 	// Get task ID from URL parameter
 	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid task ID",
-		})
+		respondError(c, apperrors.ErrInvalidTaskID)
 		return
 	}
 
 	// Get user ID from context
 	userID, exists := middlewares.GetUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Unauthorized",
-		})
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	fields, err := parseFields(c.Query("fields"), taskFields)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	include, err := parseIncludes(c.Query("include"), taskIncludes)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Find task by ID and ensure it belongs to the authenticated user.
+	// updated_at is always loaded since the ETag is derived from it.
+	columns := fields
+	if columns != nil && !containsField(columns, "updated_at") {
+		columns = append(columns[:len(columns):len(columns)], "updated_at")
+	}
+	task, err := services.NewTaskService().GetTaskFields(c.Request.Context(), uint(taskID), userID, columns, include)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Find task by ID and ensure it belongs to the authenticated user
-	var task models.Task
-	result := database.GetDB().Where("id = ? AND user_id = ?", taskID, userID).First(&task)
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Task not found",
-			})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to retrieve task: " + result.Error.Error(),
-			})
+	// Let clients polling the task skip the body when it hasn't changed. The
+	// field selection, included users and envelope each change the
+	// representation.
+	variant := strings.Join(fields, ",")
+	for _, user := range []*models.User{task.User, task.Assignee} {
+		if user != nil {
+			variant += fmt.Sprintf("|user:%d:%d", user.ID, user.UpdatedAt.UnixNano())
 		}
+	}
+	if wantsEnvelope(c) {
+		variant += "|envelope"
+	}
+	etag := computeETag(task.ID, task.UpdatedAt, variant)
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
-	c.JSON(http.StatusOK, task)
+	if fields == nil {
+		respondOK(c, task)
+		return
+	}
+
+	partial, err := selectFields(task, append(fields[:len(fields):len(fields)], include...))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, partial)
 }
 
-// UpdateTask updates a task's details
+// UpdateTask replaces a task's details
+//
+//	@Summary		Update a task
+//	@Description	Replaces the task's details: omitted fields such as description, due_date or color are cleared. Use PATCH to change individual fields.
+//	@Tags			tasks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			id		path		int			true	"Task ID"
+//	@Param			task	body		TaskRequest	true	"Updated task"
+//	@Success		200		{object}	models.Task
+//	@Failure		400		{object}	apperrors.Response
+//	@Failure		401		{object}	apperrors.Response
+//	@Failure		403		{object}	apperrors.Response
+//	@Failure		404		{object}	apperrors.Response
+//	@Failure		409		{object}	apperrors.Response
+//	@Failure		422		{object}	apperrors.Response
+//	@Failure		428		{object}	apperrors.Response
+//	@Failure		500		{object}	apperrors.Response
+//	@Router			/tasks/{id} [put]
 func UpdateTask(c *gin.Context) {
 	// Get task ID from URL parameter
 	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid task ID",
-		})
+		respondError(c, apperrors.ErrInvalidTaskID)
 		return
 	}
 
 	// Parse request body
 	var req TaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data: " + err.Error(),
-		})
+		respondError(c, validationError(err))
 		return
 	}
 
 	// Get user ID from context
 	userID, exists := middlewares.GetUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Unauthorized",
-		})
+		respondError(c, apperrors.ErrUnauthorized)
 		return
 	}
 
-	// Find task by ID and ensure it belongs to the authenticated user
-	var task models.Task
-	result := database.GetDB().Where("id = ? AND user_id = ?", taskID, userID).First(&task)
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Task not found",
-			})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to retrieve task: " + result.Error.Error(),
-			})
-		}
+	// Update the task if it belongs to the authenticated user
+	task, err := services.NewTaskService().UpdateTask(c.Request.Context(), uint(taskID), services.TaskRequest{
+		Title:         req.Title,
+		Description:   req.Description,
+		DueDate:       req.DueDate,
+		RemindAt:      req.RemindAt,
+		Priority:      req.Priority,
+		Color:         req.Color,
+		UserID:        userID,
+		Version:       req.Version,
+		LastWriteWins: middlewares.IsLegacyAPI(c),
+	})
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Update task fields
-	task.Title = req.Title
-	task.Description = req.Description
-	task.DueDate = req.DueDate
-	if req.Priority != "" {
-		task.Priority = req.Priority
+	publishTaskEvent(userID, services.TaskUpdated, task)
+	respondOK(c, task)
+}
+
+// PatchTask updates only the fields present in the request body
+//
+//	@Summary		Partially update a task
+//	@Description	Changes only the fields present in the body. Send "due_date": null or "remind_at": null to clear them.
+//	@Tags			tasks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			id		path		int					true	"Task ID"
+//	@Param			task	body		TaskPatchRequest	true	"Fields to change"
+//	@Success		200		{object}	models.Task
+//	@Failure		400		{object}	apperrors.Response
+//	@Failure		401		{object}	apperrors.Response
+//	@Failure		403		{object}	apperrors.Response
+//	@Failure		404		{object}	apperrors.Response
+//	@Failure		409		{object}	apperrors.Response
+//	@Failure		422		{object}	apperrors.Response
+//	@Failure		428		{object}	apperrors.Response
+//	@Failure		500		{object}	apperrors.Response
+//	@Router			/tasks/{id} [patch]
+func PatchTask(c *gin.Context) {
+	// Get task ID from URL parameter
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrInvalidTaskID)
+		return
 	}
 
-	// Save updated task
-	if err := database.GetDB().Save(&task).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update task: " + err.Error(),
-		})
+	// Parse request body
+	var req TaskPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, validationError(err))
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	// Update the task if it belongs to the authenticated user
+	task, err := services.NewTaskService().PatchTask(c.Request.Context(), uint(taskID), services.TaskPatch{
+		Title:         req.Title,
+		Description:   req.Description,
+		DueDate:       services.OptionalTime{Set: req.DueDate.Set, Time: req.DueDate.Time},
+		RemindAt:      services.OptionalTime{Set: req.RemindAt.Set, Time: req.RemindAt.Time},
+		Priority:      req.Priority,
+		Color:         req.Color,
+		UserID:        userID,
+		Version:       req.Version,
+		LastWriteWins: middlewares.IsLegacyAPI(c),
+	})
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, task)
+	publishTaskEvent(userID, services.TaskUpdated, task)
+	respondOK(c, task)
 }
 
 // UpdateTaskStatus updates only the status of a task
+//
+//	@Summary	Update a task's status
+//	@Tags		tasks
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Param		id		path		int					true	"Task ID"
+//	@Param		status	body		TaskStatusRequest	true	"New status"
+//	@Success	200		{object}	models.Task
+//	@Failure	400		{object}	apperrors.Response
+//	@Failure	401		{object}	apperrors.Response
+//	@Failure	403		{object}	apperrors.Response
+//	@Failure	404		{object}	apperrors.Response
+//	@Failure	409		{object}	apperrors.Response
+//	@Failure	422		{object}	apperrors.Response
+//	@Failure	500		{object}	apperrors.Response
+//	@Router		/tasks/{id}/status [patch]
 func UpdateTaskStatus(c *gin.Context) {
 	// Get task ID from URL parameter
 	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid task ID",
-		})
+		respondError(c, apperrors.ErrInvalidTaskID)
 		return
 	}
 
 	// Parse request body
 	var req TaskStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data: " + err.Error(),
-		})
+		respondError(c, validationError(err))
 		return
 	}
 
 	// Get user ID from context
 	userID, exists := middlewares.GetUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Unauthorized",
-		})
+		respondError(c, apperrors.ErrUnauthorized)
 		return
 	}
 
-	// Find task by ID and ensure it belongs to the authenticated user
-	var task models.Task
-	result := database.GetDB().Where("id = ? AND user_id = ?", taskID, userID).First(&task)
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Task not found",
-			})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to retrieve task: " + result.Error.Error(),
-			})
-		}
+	// Update the status if the task belongs to the authenticated user
+	task, err := services.NewTaskService().UpdateTaskStatus(c.Request.Context(), uint(taskID), services.TaskStatusRequest{
+		Status: req.Status,
+		UserID: userID,
+	})
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Update task status
-	task.Status = req.Status
+	publishTaskEvent(userID, services.TaskUpdated, task)
+	respondOK(c, task)
+}
 
-	// Save updated task
-	if err := database.GetDB().Save(&task).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update task status: " + err.Error(),
-		})
+// SnoozeTask moves a task's due date forward
+//
+//	@Summary		Snooze a task
+//	@Description	Makes the task due after the given duration from now, or at the current time of day tomorrow or in a week. A reminder moves along with the due date and is sent again. The new due date must be later than the current one.
+//	@Tags			tasks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			id		path		int					true	"Task ID"
+//	@Param			snooze	body		SnoozeTaskRequest	true	"How long to snooze the task"
+//	@Success		200		{object}	models.Task
+//	@Failure		400		{object}	apperrors.Response
+//	@Failure		401		{object}	apperrors.Response
+//	@Failure		403		{object}	apperrors.Response
+//	@Failure		404		{object}	apperrors.Response
+//	@Failure		409		{object}	apperrors.Response
+//	@Failure		422		{object}	apperrors.Response
+//	@Failure		500		{object}	apperrors.Response
+//	@Router			/tasks/{id}/snooze [post]
+func SnoozeTask(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrInvalidTaskID)
 		return
 	}
 
-	c.JSON(http.StatusOK, task)
-}
-
-// DeleteTask deletes a task by its ID
-func DeleteTask(c *gin.Context) {
-	// Get task ID from URL parameter
-	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req SnoozeTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, validationError(err))
+		return
+	}
+	until, err := snoozeUntil(req, time.Now())
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid task ID",
-		})
+		respondError(c, err)
 		return
 	}
 
-	// Get user ID from context
 	userID, exists := middlewares.GetUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Unauthorized",
-		})
+		respondError(c, apperrors.ErrUnauthorized)
 		return
 	}
 
-	// Check if task exists and belongs to the user
-	var task models.Task
-	result := database.GetDB().Where("id = ? AND user_id = ?", taskID, userID).First(&task)
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Task not found",
-			})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to retrieve task: " + result.Error.Error(),
-			})
-		}
+	task, err := services.NewTaskService().SnoozeTask(c.Request.Context(), uint(taskID), userID, until)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Delete the task (soft delete with GORM)
-	if err := database.GetDB().Delete(&task).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete task: " + err.Error(),
-		})
-		return
+	publishTaskEvent(userID, services.TaskUpdated, task)
+	respondOK(c, task)
+}
+
+// snoozeUntil returns the due date a snooze request asks for. Presets count
+// days in the configured time zone so that they keep the time of day across
+// daylight saving changes.
+func snoozeUntil(req SnoozeTaskRequest, now time.Time) (time.Time, error) {
+	switch {
+	case req.Duration != "" && req.Preset != "":
+		return time.Time{}, apperrors.ErrValidation.WithFields(map[string]string{"preset": "must not be given together with duration"})
+	case req.Preset == "tomorrow":
+		return now.In(config.Location()).AddDate(0, 0, 1), nil
+	case req.Preset == "next_week":
+		return now.In(config.Location()).AddDate(0, 0, 7), nil
+	case req.Duration == "":
+		return time.Time{}, apperrors.ErrValidation.WithFields(map[string]string{"duration": "is required unless preset is given"})
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Task deleted successfully",
-	})
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil || d <= 0 {
+		return time.Time{}, apperrors.ErrValidation.WithFields(map[string]string{"duration": "must be a positive duration such as 30m or 2h"})
+	}
+	return now.Add(d), nil
 }
 
-// GetTasks retrieves a list of tasks with pagination, filtering, and sorting
-func GetTasks(c *gin.Context) {
-	// Get user ID from context
-	userID, exists := middlewares.GetUserID(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Unauthorized",
-		})
+// PinTask pins or unpins a task
+//
+//	@Summary		Pin or unpin a task
+//	@Description	Pinned tasks can be listed first with pinned_first=true or on their own with pinned=true. Setting the current state again changes nothing.
+//	@Tags			tasks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			id		path		int				true	"Task ID"
+//	@Param			pin		body		PinTaskRequest	true	"Whether the task is pinned"
+//	@Success		200		{object}	models.Task
+//	@Failure		400		{object}	apperrors.Response
+//	@Failure		401		{object}	apperrors.Response
+//	@Failure		403		{object}	apperrors.Response
+//	@Failure		404		{object}	apperrors.Response
+//	@Failure		409		{object}	apperrors.Response
+//	@Failure		422		{object}	apperrors.Response
+//	@Failure		500		{object}	apperrors.Response
+//	@Router			/tasks/{id}/pin [patch]
+func PinTask(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrInvalidTaskID)
 		return
 	}
 
-	// Parse pagination parameters
-	var pagination PaginationQuery
-	if err := c.ShouldBindQuery(&pagination); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid pagination parameters: " + err.Error(),
-		})
+	var req PinTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, validationError(err))
 		return
 	}
 
-	// Set default pagination values if not provided
-	page := pagination.Page
-	if page == 0 {
-		page = 1
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
 	}
 
-	pageSize := pagination.PageSize
-	if pageSize == 0 {
-		pageSize = 10
+	task, err := services.NewTaskService().PinTask(c.Request.Context(), uint(taskID), userID, *req.Pinned)
+	if err != nil {
+		respondError(c, err)
+		return
 	}
 
-	// Calculate offset
-	offset := (page - 1) * pageSize
+	publishTaskEvent(userID, services.TaskUpdated, task)
+	respondOK(c, task)
+}
 
-	// Parse filter parameters
-	var filter TaskFilterQuery
-	if err := c.ShouldBindQuery(&filter); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid filter parameters: " + err.Error(),
-		})
+// ArchiveTask archives a task
+//
+//	@Summary		Archive a task
+//	@Description	Hides the task from the task list, count, upcoming tasks and board without deleting it. List it again with include_archived=true. Archiving an archived task keeps its archived_at.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			id	path		int	true	"Task ID"
+//	@Success		200	{object}	models.Task
+//	@Failure		400	{object}	apperrors.Response
+//	@Failure		401	{object}	apperrors.Response
+//	@Failure		403	{object}	apperrors.Response
+//	@Failure		404	{object}	apperrors.Response
+//	@Failure		409	{object}	apperrors.Response
+//	@Failure		500	{object}	apperrors.Response
+//	@Router			/tasks/{id}/archive [post]
+func ArchiveTask(c *gin.Context) {
+	setTaskArchived(c, true)
+}
+
+// UnarchiveTask returns an archived task to the task lists
+//
+//	@Summary	Unarchive a task
+//	@Tags		tasks
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Param		id	path		int	true	"Task ID"
+//	@Success	200	{object}	models.Task
+//	@Failure	400	{object}	apperrors.Response
+//	@Failure	401	{object}	apperrors.Response
+//	@Failure	403	{object}	apperrors.Response
+//	@Failure	404	{object}	apperrors.Response
+//	@Failure	409	{object}	apperrors.Response
+//	@Failure	500	{object}	apperrors.Response
+//	@Router		/tasks/{id}/unarchive [post]
+func UnarchiveTask(c *gin.Context) {
+	setTaskArchived(c, false)
+}
+
+// setTaskArchived archives or unarchives the task in the URL
+func setTaskArchived(c *gin.Context, archived bool) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrInvalidTaskID)
 		return
 	}
 
-	// Start building the query
-	query := database.GetDB().Model(&models.Task{}).Where("user_id = ?", userID)
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
 
-	// Apply filters if provided
-	if filter.Status != "" {
-		query = query.Where("status = ?", filter.Status)
+	taskService := services.NewTaskService()
+	var task *models.Task
+	if archived {
+		task, err = taskService.ArchiveTask(c.Request.Context(), uint(taskID), userID)
+	} else {
+		task, err = taskService.UnarchiveTask(c.Request.Context(), uint(taskID), userID)
+	}
+	if err != nil {
+		respondError(c, err)
+		return
 	}
-	if filter.Priority != "" {
-		query = query.Where("priority = ?", filter.Priority)
+
+	publishTaskEvent(userID, services.TaskUpdated, task)
+	respondOK(c, task)
+}
+
+// DeleteTask deletes a task by its ID
+//
+//	@Summary		Delete a task
+//	@Description	Soft-deletes the task. With permanent=true the task and its activity log are removed for good; confirm must then repeat the task ID. Admins may permanently delete any task.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			id			path		int		true	"Task ID"
+//	@Param			permanent	query		bool	false	"Delete permanently instead of soft-deleting"
+//	@Param			confirm		query		string	false	"The task ID again, required with permanent=true"
+//	@Success		200			{object}	MessageResponse
+//	@Failure		400			{object}	apperrors.Response
+//	@Failure		401			{object}	apperrors.Response
+//	@Failure		403			{object}	apperrors.Response
+//	@Failure		404			{object}	apperrors.Response
+//	@Failure		500			{object}	apperrors.Response
+//	@Router			/tasks/{id} [delete]
+func DeleteTask(c *gin.Context) {
+	// Get task ID from URL parameter
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrInvalidTaskID)
+		return
 	}
 
-	// Determine sorting
-	sortBy := "created_at" // default sort field
-	if filter.SortBy != "" {
-		sortBy = filter.SortBy
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
 	}
 
-	order := "desc" // default order
-	if filter.Order != "" {
-		order = filter.Order
+	var query DeleteTaskQuery
+	if err := bindQuery(c, &query); err != nil {
+		respondError(c, err)
+		return
 	}
 
-	// Get total count of matching tasks
-	var totalTasks int64
-	if err := query.Count(&totalTasks).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to count tasks: " + err.Error(),
-		})
+	// Permanent deletes can't be undone, so the client has to confirm them
+	// by repeating the task ID
+	if query.Permanent && query.Confirm != strconv.FormatUint(taskID, 10) {
+		respondError(c, apperrors.ErrBadRequest.WithMessage("Permanent deletion requires confirm="+strconv.FormatUint(taskID, 10)))
 		return
 	}
 
-	// Apply sorting, pagination, and execute query
-	var tasks []models.Task
-	if err := query.Order(sortBy + " " + order).
-		Limit(pageSize).
-		Offset(offset).
-		Find(&tasks).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve tasks: " + err.Error(),
-		})
+	// Delete the task (soft delete unless permanent) if it belongs to the user
+	if err := services.NewTaskService().DeleteTask(c.Request.Context(), uint(taskID), userID, query.Permanent); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Calculate total pages
-	totalPages := (totalTasks + int64(pageSize) - 1) / int64(pageSize)
+	services.TaskEvents().Publish(userID, services.TaskEvent{
+		Type:   services.TaskDeleted,
+		TaskID: uint(taskID),
+	})
 
-	// Return response with pagination metadata
-	c.JSON(http.StatusOK, gin.H{
-		"tasks": tasks,
-		"pagination": gin.H{
-			"current_page": page,
-			"page_size":    pageSize,
-			"total_items":  totalTasks,
-			"total_pages":  totalPages,
-		},
+	message := "Task deleted successfully"
+	if query.Permanent {
+		message = "Task permanently deleted"
+	}
+	respondOK(c, MessageResponse{
+		Message: message,
 	})
-}
\ No newline at end of file
+}
+
+// GetTasks retrieves a list of tasks with pagination, filtering, and sorting
+//
+//	@Summary	List tasks
+//	@Tags		tasks
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Param		page		query		int		false	"Page number"		default(1)
+//	@Param		page_size	query		int		false	"Tasks per page"	default(10)
+//	@Param		status		query		string	false	"Filter by status"		Enums(todo, in_progress, completed)
+//	@Param		priority	query		string	false	"Filter by priority"	Enums(low, medium, high)
+//	@Param		sort_by		query		string	false	"Sort field; position is the manual order and sorts ascending by default"	Enums(created_at, due_date, priority, title, position)
+//	@Param		order		query		string	false	"Sort order"			Enums(asc, desc)
+//	@Param		assigned_to_me	query	bool	false	"Include tasks assigned to me"
+//	@Param		shared_with_me	query	bool	false	"Include tasks shared with me"
+//	@Param		has_due_date	query	bool	false	"Only tasks with (true) or without (false) a due date"
+//	@Param		created_after	query	string	false	"Only tasks created at or after this RFC 3339 time, e.g. 2024-03-01T00:00:00Z"
+//	@Param		created_before	query	string	false	"Only tasks created before this RFC 3339 time; must be after created_after"
+//	@Param		pinned		query		bool	false	"Only pinned (true) or unpinned (false) tasks"
+//	@Param		pinned_first	query	bool	false	"List pinned tasks before the others"
+//	@Param		include_archived	query	bool	false	"Also list archived tasks"
+//	@Param		fields		query		string	false	"Comma-separated task fields to return, e.g. id,title,status (id is always included)"
+//	@Param		include		query		string	false	"Comma-separated relations to embed: user (the owner), assignee"
+//	@Param		search		query		string	false	"Only tasks whose title or description contains this text; title matches rank first"
+//	@Param		search_mode	query		string	false	"substring matches any part of the text; fulltext uses the MySQL fulltext index in boolean mode, ranks by relevance and returns scores"	Enums(substring, fulltext)	default(substring)
+//	@Param		highlight	query		bool	false	"Return snippets of the search matches"
+//	@Success	200			{object}	TaskListResponse
+//	@Failure	400			{object}	apperrors.Response
+//	@Failure	401			{object}	apperrors.Response
+//	@Failure	422			{object}	apperrors.Response
+//	@Failure	500			{object}	apperrors.Response
+//	@Router		/tasks [get]
+func GetTasks(c *gin.Context) {
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	// Parse pagination and filter parameters
+	var pagination PaginationQuery
+	var filter TaskFilterQuery
+	if err := bindQuery(c, &pagination, &filter); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	fields, err := parseFields(filter.Fields, taskFields)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	include, err := parseIncludes(filter.Include, taskIncludes)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	result, err := services.NewTaskService().GetTasks(c.Request.Context(), services.TaskFilterOptions{
+		UserID:          userID,
+		AssignedToMe:    filter.AssignedToMe,
+		SharedWithMe:    filter.SharedWithMe,
+		Status:          filter.Status,
+		Priority:        filter.Priority,
+		HasDueDate:      filter.HasDueDate,
+		CreatedAfter:    filter.CreatedAfter,
+		CreatedBefore:   filter.CreatedBefore,
+		Pinned:          filter.Pinned,
+		PinnedFirst:     filter.PinnedFirst,
+		IncludeArchived: filter.IncludeArchived,
+		SortBy:          filter.SortBy,
+		Order:           filter.Order,
+		Page:            pagination.Page,
+		PageSize:        pagination.PageSize,
+		Fields:          fields,
+		Include:         include,
+		Search:          filter.Search,
+		SearchMode:      filter.SearchMode,
+		Highlight:       filter.Highlight,
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var highlights map[uint]TaskHighlight
+	if result.Highlights != nil {
+		highlights = make(map[uint]TaskHighlight, len(result.Highlights))
+		for id, h := range result.Highlights {
+			highlights[id] = TaskHighlight{
+				Title:       h.Title,
+				Description: h.Description,
+			}
+		}
+	}
+
+	meta := newPaginationMeta(c, result.CurrentPage, result.PageSize, result.TotalItems, result.TotalPages)
+	if fields == nil {
+		// Return response with pagination metadata
+		respondOK(c, TaskListResponse{
+			Tasks:      result.Tasks,
+			Highlights: highlights,
+			Scores:     result.Scores,
+			Pagination: meta,
+		})
+		return
+	}
+
+	// Included relations are returned along with the selected fields
+	selected := append(fields[:len(fields):len(fields)], include...)
+	tasks := make([]map[string]json.RawMessage, len(result.Tasks))
+	for i, task := range result.Tasks {
+		if tasks[i], err = selectFields(task, selected); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+	respondOK(c, partialTaskListResponse{
+		Tasks:      tasks,
+		Highlights: highlights,
+		Scores:     result.Scores,
+		Pagination: meta,
+	})
+}
+
+// CountTasks returns how many of the user's tasks match the filters, without
+// loading the tasks themselves
+//
+//	@Summary	Count tasks
+//	@Tags		tasks
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Param		status			query		string	false	"Filter by status"		Enums(todo, in_progress, completed)
+//	@Param		priority		query		string	false	"Filter by priority"	Enums(low, medium, high)
+//	@Param		assigned_to_me	query		bool	false	"Include tasks assigned to me"
+//	@Param		shared_with_me	query		bool	false	"Include tasks shared with me"
+//	@Param		include_archived	query	bool	false	"Also count archived tasks"
+//	@Success	200				{object}	TaskCountResponse
+//	@Failure	400				{object}	apperrors.Response
+//	@Failure	401				{object}	apperrors.Response
+//	@Failure	500				{object}	apperrors.Response
+//	@Router		/tasks/count [get]
+func CountTasks(c *gin.Context) {
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	// Parse filter parameters
+	var filter TaskCountQuery
+	if err := bindQuery(c, &filter); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	count, err := services.NewTaskService().CountTasks(c.Request.Context(), services.TaskFilterOptions{
+		UserID:          userID,
+		AssignedToMe:    filter.AssignedToMe,
+		SharedWithMe:    filter.SharedWithMe,
+		Status:          filter.Status,
+		Priority:        filter.Priority,
+		IncludeArchived: filter.IncludeArchived,
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, TaskCountResponse{Count: count})
+}
+
+// GetBoard returns the user's tasks grouped into a column per status
+//
+//	@Summary		Get the task board
+//	@Description	Returns a column per status (todo, in_progress, completed) holding its first tasks in manual order and the column's total. Fetch more of a column with GET /tasks?status=...&sort_by=position.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			limit_per_column	query		int	false	"Tasks per column"	default(10)
+//	@Success		200					{object}	BoardResponse
+//	@Failure		400					{object}	apperrors.Response
+//	@Failure		401					{object}	apperrors.Response
+//	@Failure		500					{object}	apperrors.Response
+//	@Router			/tasks/board [get]
+func GetBoard(c *gin.Context) {
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	var query BoardQuery
+	if err := bindQuery(c, &query); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	columns, err := services.NewTaskService().GetBoard(c.Request.Context(), userID, query.LimitPerColumn)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	resp := BoardResponse{Columns: make([]BoardColumnResponse, len(columns))}
+	for i, column := range columns {
+		resp.Columns[i] = BoardColumnResponse{
+			Status: column.Status,
+			Tasks:  column.Tasks,
+			Total:  column.Total,
+		}
+	}
+	respondOK(c, resp)
+}
+
+// GetTasksBatch returns several tasks the user can view by ID in one request
+//
+//	@Summary		Get tasks by ID
+//	@Description	Returns the tasks with the given IDs in the order given. Tasks the user owns, or that are shared with or assigned to them, are returned; other IDs are listed in missing instead. At most 100 IDs per request.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			ids	query		string	true	"Comma-separated task IDs, e.g. 1,2,3"
+//	@Success		200	{object}	TaskBatchResponse
+//	@Failure		400	{object}	apperrors.Response
+//	@Failure		401	{object}	apperrors.Response
+//	@Failure		500	{object}	apperrors.Response
+//	@Router			/tasks/batch [get]
+func GetTasksBatch(c *gin.Context) {
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	var query TaskBatchQuery
+	if err := bindQuery(c, &query); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	var ids []uint
+	seen := make(map[uint]bool)
+	for _, raw := range strings.Split(query.IDs, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 32)
+		if err != nil {
+			respondError(c, apperrors.ErrInvalidTaskID.WithMessage(fmt.Sprintf("Invalid task ID %q in ids", raw)))
+			return
+		}
+		if !seen[uint(id)] {
+			seen[uint(id)] = true
+			ids = append(ids, uint(id))
+		}
+	}
+	if len(ids) > maxBatchTaskIDs {
+		respondError(c, apperrors.ErrBadRequest.WithMessage(
+			fmt.Sprintf("At most %d task IDs can be requested at once", maxBatchTaskIDs)))
+		return
+	}
+
+	tasks, err := services.NewTaskService().GetTasksByIDs(c.Request.Context(), ids, userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	resp := TaskBatchResponse{Tasks: tasks, Missing: []uint{}}
+	for _, task := range tasks {
+		delete(seen, task.ID)
+	}
+	for _, id := range ids {
+		if seen[id] {
+			resp.Missing = append(resp.Missing, id)
+		}
+	}
+	respondOK(c, resp)
+}
+
+// GetCompletedReport lists the user's tasks completed in a date range by day
+//
+//	@Summary		Get completed tasks per day
+//	@Description	Returns the user's completed tasks grouped by the day they were completed, for every day from from to to inclusive. Days are in the configured time zone. Tasks completed before completion times were recorded count on the day they were last updated.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			from	query		string	true	"First day, e.g. 2024-03-01"
+//	@Param			to		query		string	true	"Last day, e.g. 2024-03-31; at most 366 days after from"
+//	@Success		200		{object}	CompletedReportResponse
+//	@Failure		400		{object}	apperrors.Response
+//	@Failure		401		{object}	apperrors.Response
+//	@Failure		500		{object}	apperrors.Response
+//	@Router			/tasks/completed [get]
+func GetCompletedReport(c *gin.Context) {
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	var query CompletedReportQuery
+	if err := bindQuery(c, &query); err != nil {
+		respondError(c, err)
+		return
+	}
+	// The binding already checked the format
+	from, _ := time.ParseInLocation("2006-01-02", query.From, config.Location())
+	to, _ := time.ParseInLocation("2006-01-02", query.To, config.Location())
+	if to.Before(from) {
+		respondError(c, apperrors.ErrBadRequest.WithMessage("to must not be before from"))
+		return
+	}
+	// Include the whole last day
+	to = to.AddDate(0, 0, 1)
+	if to.After(from.AddDate(0, 0, maxCompletedReportDays)) {
+		respondError(c, apperrors.ErrBadRequest.WithMessage(
+			"The range must not be longer than "+strconv.Itoa(maxCompletedReportDays)+" days"))
+		return
+	}
+
+	days, err := services.NewTaskService().CompletedReport(c.Request.Context(), userID, from, to)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	resp := CompletedReportResponse{Days: make([]CompletedDayResponse, len(days))}
+	for i, day := range days {
+		resp.Days[i] = CompletedDayResponse{
+			Date:  day.Date,
+			Count: len(day.Tasks),
+			Tasks: day.Tasks,
+		}
+		resp.Total += len(day.Tasks)
+	}
+	respondOK(c, resp)
+}
+
+// GetStreak returns the user's consecutive-day task completion streaks
+//
+//	@Summary		Get completion streaks
+//	@Description	Returns how many consecutive days up to today the user completed at least one task, the longest such run and how many tasks the user has completed. The current streak is kept until the end of the day after the last completion. Days are in the configured time zone.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	StreakResponse
+//	@Failure		401	{object}	apperrors.Response
+//	@Failure		500	{object}	apperrors.Response
+//	@Router			/me/streak [get]
+func GetStreak(c *gin.Context) {
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	streak, err := services.NewTaskService().GetCompletionStreak(c.Request.Context(), userID, time.Now())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, StreakResponse{
+		CurrentStreak:  streak.Current,
+		LongestStreak:  streak.Longest,
+		TotalCompleted: streak.TotalCompleted,
+	})
+}
+
+// GetUpcomingTasks lists the user's incomplete tasks due within the next days
+//
+//	@Summary		List upcoming tasks
+//	@Description	Returns the user's tasks that aren't completed and are due within the next days, soonest first
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			days		query		int	false	"Days to look ahead, at most 365"	default(7)
+//	@Param			page		query		int	false	"Page number"						default(1)
+//	@Param			page_size	query		int	false	"Tasks per page"					default(10)
+//	@Success		200			{object}	TaskListResponse
+//	@Failure		400			{object}	apperrors.Response
+//	@Failure		401			{object}	apperrors.Response
+//	@Failure		500			{object}	apperrors.Response
+//	@Router			/tasks/upcoming [get]
+func GetUpcomingTasks(c *gin.Context) {
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	// Parse pagination and query parameters
+	var pagination PaginationQuery
+	var query UpcomingTasksQuery
+	if err := bindQuery(c, &pagination, &query); err != nil {
+		respondError(c, err)
+		return
+	}
+	days := query.Days
+	if days == 0 {
+		days = defaultUpcomingDays
+	} else if days > maxUpcomingDays {
+		days = maxUpcomingDays
+	}
+
+	now := time.Now()
+	dueBefore := now.AddDate(0, 0, days)
+	result, err := services.NewTaskService().GetTasks(c.Request.Context(), services.TaskFilterOptions{
+		UserID:     userID,
+		DueAfter:   &now,
+		DueBefore:  &dueBefore,
+		Incomplete: true,
+		SortBy:     "due_date",
+		Order:      "asc",
+		Page:       pagination.Page,
+		PageSize:   pagination.PageSize,
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, TaskListResponse{
+		Tasks:      result.Tasks,
+		Pagination: newPaginationMeta(c, result.CurrentPage, result.PageSize, result.TotalItems, result.TotalPages),
+	})
+}
+
+// AssignTask assigns a task to another user, identified by email
+//
+//	@Summary	Assign a task
+//	@Tags		tasks
+//	@Accept		json
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Param		id			path		int					true	"Task ID"
+//	@Param		assignee	body		AssignTaskRequest	true	"User to assign the task to"
+//	@Success	200			{object}	models.Task
+//	@Failure	400			{object}	apperrors.Response
+//	@Failure	401			{object}	apperrors.Response
+//	@Failure	403			{object}	apperrors.Response
+//	@Failure	404			{object}	apperrors.Response
+//	@Failure	409			{object}	apperrors.Response
+//	@Failure	422			{object}	apperrors.Response
+//	@Failure	500			{object}	apperrors.Response
+//	@Router		/tasks/{id}/assign [post]
+func AssignTask(c *gin.Context) {
+	// Get task ID from URL parameter
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrInvalidTaskID)
+		return
+	}
+
+	var req AssignTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, validationError(err))
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	// Only the task's owner can (re)assign it
+	task, err := services.NewTaskService().AssignTask(c.Request.Context(), uint(taskID), userID, req.Email)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	publishTaskEvent(userID, services.TaskUpdated, task)
+	respondOK(c, task)
+}
+
+// GetTaskActivity retrieves the change history of a task
+//
+//	@Summary	Get task activity
+//	@Tags		tasks
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Param		id			path		int	true	"Task ID"
+//	@Param		page		query		int	false	"Page number"		default(1)
+//	@Param		page_size	query		int	false	"Entries per page"	default(10)
+//	@Success	200			{object}	TaskActivityListResponse
+//	@Failure	400			{object}	apperrors.Response
+//	@Failure	401			{object}	apperrors.Response
+//	@Failure	404			{object}	apperrors.Response
+//	@Failure	500			{object}	apperrors.Response
+//	@Router		/tasks/{id}/activity [get]
+func GetTaskActivity(c *gin.Context) {
+	// Get task ID from URL parameter
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrInvalidTaskID)
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	// Parse pagination parameters
+	var pagination PaginationQuery
+	if err := bindQuery(c, &pagination); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	result, err := services.NewTaskService().GetTaskActivity(c.Request.Context(), uint(taskID), userID, pagination.Page, pagination.PageSize)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, TaskActivityListResponse{
+		Activities: result.Activities,
+		Pagination: newPaginationMeta(c, result.CurrentPage, result.PageSize, result.TotalItems, result.TotalPages),
+	})
+}
+
+// ReorderTasks rearranges the user's tasks in a manual order
+//
+//	@Summary		Reorder tasks
+//	@Description	The listed tasks trade the positions they hold among themselves, so other tasks keep their place. List tasks with sort_by=position to get the manual order.
+//	@Tags			tasks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			order	body		ReorderTasksRequest	true	"Task IDs in their new order"
+//	@Success		200		{object}	ReorderTasksResponse
+//	@Failure		400		{object}	apperrors.Response
+//	@Failure		401		{object}	apperrors.Response
+//	@Failure		404		{object}	apperrors.Response
+//	@Failure		422		{object}	apperrors.Response
+//	@Failure		500		{object}	apperrors.Response
+//	@Router			/tasks/reorder [patch]
+func ReorderTasks(c *gin.Context) {
+	// Parse request body
+	var req ReorderTasksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, validationError(err))
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	tasks, err := services.NewTaskService().ReorderTasks(c.Request.Context(), userID, req.TaskIDs)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	services.TaskEvents().Publish(userID, services.TaskEvent{
+		Type:  services.TasksReordered,
+		Count: int64(len(tasks)),
+	})
+
+	respondOK(c, ReorderTasksResponse{Tasks: tasks})
+}
+
+// AddTaskDependency makes a task depend on another task of the same user
+//
+//	@Summary		Add a task dependency
+//	@Description	The task can't be marked completed until the task it depends on is. Links that would create a cycle are rejected.
+//	@Tags			tasks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			id			path		int						true	"Task ID"
+//	@Param			dependency	body		TaskDependencyRequest	true	"Task to depend on"
+//	@Success		201			{object}	models.TaskDependency
+//	@Failure		400			{object}	apperrors.Response
+//	@Failure		401			{object}	apperrors.Response
+//	@Failure		404			{object}	apperrors.Response
+//	@Failure		409			{object}	apperrors.Response
+//	@Failure		422			{object}	apperrors.Response
+//	@Failure		500			{object}	apperrors.Response
+//	@Router			/tasks/{id}/dependencies [post]
+func AddTaskDependency(c *gin.Context) {
+	// Get task ID from URL parameter
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrInvalidTaskID)
+		return
+	}
+
+	var req TaskDependencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, validationError(err))
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	dependency, err := services.NewTaskService().AddDependency(c.Request.Context(), uint(taskID), req.DependsOnID, userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondCreated(c, dependency)
+}
+
+// GetTaskDependencies lists the tasks a task depends on
+//
+//	@Summary	Get task dependencies
+//	@Tags		tasks
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Param		id	path		int	true	"Task ID"
+//	@Success	200	{object}	TaskDependenciesResponse
+//	@Failure	400	{object}	apperrors.Response
+//	@Failure	401	{object}	apperrors.Response
+//	@Failure	404	{object}	apperrors.Response
+//	@Failure	500	{object}	apperrors.Response
+//	@Router		/tasks/{id}/dependencies [get]
+func GetTaskDependencies(c *gin.Context) {
+	// Get task ID from URL parameter
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrInvalidTaskID)
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	tasks, err := services.NewTaskService().GetDependencies(c.Request.Context(), uint(taskID), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, TaskDependenciesResponse{
+		Dependencies: tasks,
+	})
+}
+
+// ShareTask shares a task with another user, identified by email
+//
+//	@Summary		Share a task
+//	@Description	Viewers can read the task; editors can also change its fields and status. Sharing the task again with the same user changes their role. Only the task's owner can share it.
+//	@Tags			tasks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			id				path		int					true	"Task ID"
+//	@Param			collaborator	body		ShareTaskRequest	true	"User to share the task with"
+//	@Success		200				{object}	models.TaskCollaborator
+//	@Failure		400				{object}	apperrors.Response
+//	@Failure		401				{object}	apperrors.Response
+//	@Failure		403				{object}	apperrors.Response
+//	@Failure		404				{object}	apperrors.Response
+//	@Failure		422				{object}	apperrors.Response
+//	@Failure		500				{object}	apperrors.Response
+//	@Router			/tasks/{id}/collaborators [post]
+func ShareTask(c *gin.Context) {
+	// Get task ID from URL parameter
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrInvalidTaskID)
+		return
+	}
+
+	var req ShareTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, validationError(err))
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	collaborator, err := services.NewTaskService().ShareTask(c.Request.Context(), uint(taskID), userID, req.Email, models.CollaboratorRole(req.Role))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, collaborator)
+}
+
+// GetTaskCollaborators lists the users a task is shared with
+//
+//	@Summary	Get task collaborators
+//	@Tags		tasks
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Security	ApiKeyAuth
+//	@Param		id	path		int	true	"Task ID"
+//	@Success	200	{object}	TaskCollaboratorsResponse
+//	@Failure	400	{object}	apperrors.Response
+//	@Failure	401	{object}	apperrors.Response
+//	@Failure	404	{object}	apperrors.Response
+//	@Failure	500	{object}	apperrors.Response
+//	@Router		/tasks/{id}/collaborators [get]
+func GetTaskCollaborators(c *gin.Context) {
+	// Get task ID from URL parameter
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrInvalidTaskID)
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	collaborators, err := services.NewTaskService().GetCollaborators(c.Request.Context(), uint(taskID), userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, TaskCollaboratorsResponse{
+		Collaborators: collaborators,
+	})
+}
+
+// UnshareTask stops sharing a task with a user
+//
+//	@Summary		Unshare a task
+//	@Description	The task's owner can remove any collaborator; a collaborator can remove themselves.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			id		path		int	true	"Task ID"
+//	@Param			user_id	path		int	true	"ID of the user to stop sharing the task with"
+//	@Success		200		{object}	MessageResponse
+//	@Failure		400		{object}	apperrors.Response
+//	@Failure		401		{object}	apperrors.Response
+//	@Failure		403		{object}	apperrors.Response
+//	@Failure		404		{object}	apperrors.Response
+//	@Failure		500		{object}	apperrors.Response
+//	@Router			/tasks/{id}/collaborators/{user_id} [delete]
+func UnshareTask(c *gin.Context) {
+	// Get task ID from URL parameter
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrInvalidTaskID)
+		return
+	}
+	collaboratorID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		respondError(c, apperrors.ErrBadRequest.WithMessage("Invalid user ID"))
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	if err := services.NewTaskService().UnshareTask(c.Request.Context(), uint(taskID), userID, uint(collaboratorID)); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, MessageResponse{
+		Message: "Task unshared successfully",
+	})
+}
+
+// DeleteTasks deletes all of the user's tasks matching a filter
+//
+//	@Summary		Delete tasks by filter
+//	@Description	Deletes every task matching the filters. At least one filter is required. With dry_run=true the matching tasks are only counted.
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Security		ApiKeyAuth
+//	@Param			status		query		string	false	"Filter by status"		Enums(todo, in_progress, completed)
+//	@Param			priority	query		string	false	"Filter by priority"	Enums(low, medium, high)
+//	@Param			dry_run		query		bool	false	"Only count the matching tasks"
+//	@Success		200			{object}	DeleteTasksResponse
+//	@Failure		400			{object}	apperrors.Response
+//	@Failure		401			{object}	apperrors.Response
+//	@Failure		500			{object}	apperrors.Response
+//	@Router			/tasks [delete]
+func DeleteTasks(c *gin.Context) {
+	// Get user ID from context
+	userID, exists := middlewares.GetUserID(c)
+	if !exists {
+		respondError(c, apperrors.ErrUnauthorized)
+		return
+	}
+
+	// Parse filter parameters
+	var query DeleteTasksQuery
+	if err := bindQuery(c, &query); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	deleted, err := services.NewTaskService().DeleteByFilter(c.Request.Context(), services.TaskFilterOptions{
+		UserID:   userID,
+		Status:   query.Status,
+		Priority: query.Priority,
+	}, query.DryRun)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if !query.DryRun && deleted > 0 {
+		services.TaskEvents().Publish(userID, services.TaskEvent{
+			Type:  services.TasksBulkDeleted,
+			Count: deleted,
+		})
+	}
+
+	respondOK(c, DeleteTasksResponse{
+		Deleted: deleted,
+		DryRun:  query.DryRun,
+	})
+}
@@ -0,0 +1,193 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestUpdateTaskVersion(t *testing.T) {
+	h := newHarness(t)
+	user, token := seedUser(t, h, "alice")
+	task := seedTask(t, h, user.ID, "Write report")
+	path := fmt.Sprintf("/api/v1/tasks/%d", task.ID)
+
+	t.Run("missing version", func(t *testing.T) {
+		w := do(t, h, http.MethodPut, path, token, map[string]interface{}{"title": "Edited"}, http.StatusPreconditionRequired)
+		if code := errorCode(t, w); code != "version_required" {
+			t.Errorf("got error code %q, want version_required", code)
+		}
+		do(t, h, http.MethodPatch, path, token, map[string]interface{}{"title": "Edited"}, http.StatusPreconditionRequired)
+	})
+
+	t.Run("current version", func(t *testing.T) {
+		w := do(t, h, http.MethodPut, path, token, map[string]interface{}{"title": "Edited", "version": 1}, http.StatusOK)
+		var updated taskBody
+		decode(t, w, &updated)
+		if updated.Version != 2 || updated.Title != "Edited" {
+			t.Errorf("got version %d and title %q, want 2 and Edited", updated.Version, updated.Title)
+		}
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		// Version 1 was replaced by the update above
+		w := do(t, h, http.MethodPut, path, token, map[string]interface{}{"title": "Lost update", "version": 1}, http.StatusConflict)
+		if code := errorCode(t, w); code != "version_conflict" {
+			t.Errorf("got error code %q, want version_conflict", code)
+		}
+		do(t, h, http.MethodPatch, path, token, map[string]interface{}{"title": "Lost update", "version": 1}, http.StatusConflict)
+
+		w = do(t, h, http.MethodGet, path, token, nil, http.StatusOK)
+		var current taskBody
+		decode(t, w, &current)
+		if current.Title != "Edited" || current.Version != 2 {
+			t.Errorf("got title %q at version %d, want the earlier update kept", current.Title, current.Version)
+		}
+	})
+}
+
+func TestUpdateTaskVersionLegacyAPI(t *testing.T) {
+	h := newHarness(t)
+	user, token := seedUser(t, h, "alice")
+	task := seedTask(t, h, user.ID, "Write report")
+	path := fmt.Sprintf("/api/tasks/%d", task.ID)
+
+	// Clients of the unversioned alias predate versions: the last write wins
+	var updated taskBody
+	decode(t, do(t, h, http.MethodPut, path, token, map[string]interface{}{"title": "Edited"}, http.StatusOK), &updated)
+	if updated.Version != 2 || updated.Title != "Edited" {
+		t.Errorf("PUT got version %d and title %q, want 2 and Edited", updated.Version, updated.Title)
+	}
+	decode(t, do(t, h, http.MethodPatch, path, token, map[string]interface{}{"title": "Patched"}, http.StatusOK), &updated)
+	if updated.Version != 3 || updated.Title != "Patched" {
+		t.Errorf("PATCH got version %d and title %q, want 3 and Patched", updated.Version, updated.Title)
+	}
+
+	// A version that is sent is still checked
+	do(t, h, http.MethodPut, path, token, map[string]interface{}{"title": "Lost update", "version": 1}, http.StatusConflict)
+	do(t, h, http.MethodPatch, path, token, map[string]interface{}{"title": "Lost update", "version": 1}, http.StatusConflict)
+}
+
+func TestTaskDueDate(t *testing.T) {
+	h := newHarness(t)
+	user, token := seedUser(t, h, "alice")
+	task := seedTask(t, h, user.ID, "Write report")
+	path := fmt.Sprintf("/api/v1/tasks/%d", task.ID)
+	const due = "2030-01-02T15:04:05Z"
+
+	// dueDate sends the request and returns the due date in the response
+	dueDate := func(t *testing.T, method string, body map[string]interface{}) *string {
+		t.Helper()
+		w := do(t, h, method, path, token, body, http.StatusOK)
+		var updated taskBody
+		decode(t, w, &updated)
+		return updated.DueDate
+	}
+
+	t.Run("set", func(t *testing.T) {
+		got := dueDate(t, http.MethodPatch, map[string]interface{}{"due_date": due, "version": 1})
+		if got == nil || *got != due {
+			t.Errorf("got due date %v, want %s", got, due)
+		}
+	})
+
+	t.Run("leave unchanged", func(t *testing.T) {
+		got := dueDate(t, http.MethodPatch, map[string]interface{}{"title": "Write the report", "version": 2})
+		if got == nil || *got != due {
+			t.Errorf("got due date %v, want %s kept", got, due)
+		}
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		if got := dueDate(t, http.MethodPatch, map[string]interface{}{"due_date": nil, "version": 3}); got != nil {
+			t.Errorf("got due date %s, want it cleared", *got)
+		}
+	})
+
+	t.Run("put replaces", func(t *testing.T) {
+		if got := dueDate(t, http.MethodPut, map[string]interface{}{"title": "Report", "due_date": due, "version": 4}); got == nil {
+			t.Fatal("PUT didn't set the due date")
+		}
+		// PUT replaces every field, so leaving due_date out clears it
+		if got := dueDate(t, http.MethodPut, map[string]interface{}{"title": "Report", "version": 5}); got != nil {
+			t.Errorf("got due date %s, want it cleared", *got)
+		}
+	})
+}
+
+func TestTaskEnumRoundTrip(t *testing.T) {
+	h := newHarness(t)
+	user, token := seedUser(t, h, "alice")
+
+	t.Run("valid", func(t *testing.T) {
+		w := do(t, h, http.MethodPost, "/api/v1/tasks/", token, map[string]interface{}{"title": "Ship", "priority": "high"}, http.StatusCreated)
+		var created taskBody
+		decode(t, w, &created)
+
+		path := fmt.Sprintf("/api/v1/tasks/%d", created.ID)
+		do(t, h, http.MethodPatch, path+"/status", token, map[string]interface{}{"status": "in_progress"}, http.StatusOK)
+
+		w = do(t, h, http.MethodGet, path, token, nil, http.StatusOK)
+		var got taskBody
+		decode(t, w, &got)
+		if got.Priority != "high" || got.Status != "in_progress" {
+			t.Errorf("got priority %q and status %q, want high and in_progress", got.Priority, got.Status)
+		}
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		w := do(t, h, http.MethodPost, "/api/v1/tasks/", token, map[string]interface{}{"title": "Ship", "priority": "urgent"}, http.StatusUnprocessableEntity)
+		if code := errorCode(t, w); code != "validation_failed" {
+			t.Errorf("got error code %q, want validation_failed", code)
+		}
+
+		task := seedTask(t, h, user.ID, "Ship")
+		path := fmt.Sprintf("/api/v1/tasks/%d/status", task.ID)
+		do(t, h, http.MethodPatch, path, token, map[string]interface{}{"status": "done"}, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("corrupt row", func(t *testing.T) {
+		task := seedTask(t, h, user.ID, "Ship")
+		// Write around the model's hooks, as a bad migration or manual edit would
+		if err := h.DB.Exec("UPDATE tasks SET status = 'bogus' WHERE id = ?", task.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		w := do(t, h, http.MethodGet, fmt.Sprintf("/api/v1/tasks/%d", task.ID), token, nil, http.StatusInternalServerError)
+		if code := errorCode(t, w); code != "internal_error" {
+			t.Errorf("got error code %q, want internal_error", code)
+		}
+	})
+}
+
+func TestTaskAssigneeAccess(t *testing.T) {
+	h := newHarness(t)
+	owner, ownerToken := seedUser(t, h, "owner")
+	_, assigneeToken := seedUser(t, h, "assignee")
+	_, otherToken := seedUser(t, h, "other")
+	task := seedTask(t, h, owner.ID, "Review the draft")
+	path := fmt.Sprintf("/api/v1/tasks/%d", task.ID)
+
+	do(t, h, http.MethodPost, path+"/assign", ownerToken, map[string]string{"email": "assignee@example.com"}, http.StatusOK)
+
+	// The assignee can view and edit the task
+	var got taskBody
+	decode(t, do(t, h, http.MethodGet, path, assigneeToken, nil, http.StatusOK), &got)
+	if got.ID != task.ID {
+		t.Errorf("got task %d, want %d", got.ID, task.ID)
+	}
+	decode(t, do(t, h, http.MethodGet, path+"?fields=title", assigneeToken, nil, http.StatusOK), &got)
+	decode(t, do(t, h, http.MethodPatch, path+"/status", assigneeToken, map[string]string{"status": "completed"}, http.StatusOK), &got)
+	if got.Status != "completed" {
+		t.Errorf("status is %q after the assignee completed the task", got.Status)
+	}
+
+	// Only the owner can manage it
+	w := do(t, h, http.MethodDelete, path, assigneeToken, nil, http.StatusForbidden)
+	if code := errorCode(t, w); code != "forbidden" {
+		t.Errorf("got error code %q, want forbidden", code)
+	}
+
+	// Other users still can't see it
+	do(t, h, http.MethodGet, path, otherToken, nil, http.StatusNotFound)
+}
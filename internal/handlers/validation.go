@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"task-manager/config"
+	"task-manager/internal/apperrors"
+	"task-manager/internal/models"
+)
+
+func init() {
+	// Report validation errors using the JSON (or query) field names clients
+	// actually send instead of the Go struct field names
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(fieldName)
+
+		// max_page_size checks against the configured MAX_PAGE_SIZE, which
+		// can't be written into a static max= tag
+		_ = v.RegisterValidation("max_page_size", func(fl validator.FieldLevel) bool {
+			return fl.Field().Int() <= int64(config.GetConfig().Pagination.MaxPageSize)
+		})
+
+		v.RegisterStructValidation(validateTaskFilter, TaskFilterQuery{})
+	}
+}
+
+// validateTaskFilter checks that the creation time range of a task filter
+// isn't empty
+func validateTaskFilter(sl validator.StructLevel) {
+	filter := sl.Current().Interface().(TaskFilterQuery)
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		sl.ReportError(filter.CreatedAfter, "created_after", "CreatedAfter", "before_created_before", "")
+	}
+}
+
+// fieldName returns the client-facing name of a struct field
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// validationError converts a binding error into an AppError. Validator errors
+// and unknown enum values become a 422 with a field→message map and
+// oversized bodies a 413; anything
+// else (e.g. malformed JSON) is reported as a bad request.
+func validationError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return apperrors.ErrPayloadTooLarge.WithMessage(fmt.Sprintf("Request body must not exceed %d bytes", maxBytesErr.Limit))
+	}
+
+	// Unknown priorities and statuses are caught while decoding, before the
+	// validator runs, but are reported the same way
+	var invalidValue *models.InvalidValueError
+	if errors.As(err, &invalidValue) {
+		return apperrors.ErrValidation.WithFields(map[string]string{invalidValue.Field: invalidValue.Reason()})
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return apperrors.ErrBadRequest.WithMessage("Invalid request data: " + err.Error())
+	}
+
+	return apperrors.ErrValidation.WithFields(validationFields(validationErrs))
+}
+
+// bindQuery binds the query string into each of objs. Every parameter that
+// fails validation, whichever of objs it belongs to, is reported in a single
+// 400 with a field→message map; a value that can't be parsed at all (e.g.
+// page=abc) is reported on its own.
+func bindQuery(c *gin.Context, objs ...interface{}) error {
+	fields := make(map[string]string)
+	for _, obj := range objs {
+		err := c.ShouldBindQuery(obj)
+		if err == nil {
+			continue
+		}
+
+		var validationErrs validator.ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			return apperrors.ErrBadRequest.WithMessage("Invalid query parameters: " + err.Error())
+		}
+		maps.Copy(fields, validationFields(validationErrs))
+	}
+
+	if len(fields) > 0 {
+		return apperrors.ErrBadRequest.WithMessage("Invalid query parameters").WithFields(fields)
+	}
+	return nil
+}
+
+// validationFields maps each failed field to a human-readable message
+func validationFields(validationErrs validator.ValidationErrors) map[string]string {
+	fields := make(map[string]string, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields[fieldErr.Field()] = validationMessage(fieldErr)
+	}
+	return fields
+}
+
+// validationMessage describes a single failed validation rule
+func validationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fieldErr.Param()), ", ")
+	case "min":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fieldErr.Param())
+		}
+		return "must be at least " + fieldErr.Param()
+	case "max":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fieldErr.Param())
+		}
+		return "must be at most " + fieldErr.Param()
+	case "max_page_size":
+		return fmt.Sprintf("must be at most %d", config.GetConfig().Pagination.MaxPageSize)
+	case "before_created_before":
+		return "must be before created_before"
+	default:
+		return "is invalid"
+	}
+}
@@ -2,28 +2,40 @@ package middlewares
 
 import (
 	"errors"
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"task-manager/pkg/utils"
 	"task-manager/pkg/database"
+	"task-manager/internal/apperrors"
 	"task-manager/internal/models"
+	"task-manager/internal/services"
 )
 
-// AuthMiddleware authenticates the user by validating JWT token from request header
+// AuthMiddleware authenticates the user by validating JWT token from request
+// header. An API key in the X-API-Key header is accepted instead of a token.
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Authenticate with an API key when one is provided
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			user, err := services.NewAPIKeyService().Authenticate(apiKey)
+			if err != nil {
+				abortWithError(c, err)
+				return
+			}
+
+			setUser(c, user)
+			c.Next()
+			return
+		}
+
 		// Get the Authorization header
 		authHeader := c.GetHeader("Authorization")
 
 		// Check if Authorization header exists
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header is required",
-			})
-			c.Abort()
+			abortWithError(c, apperrors.ErrUnauthorized.WithMessage("Authorization header is required"))
 			return
 		}
 
@@ -31,79 +43,94 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Format should be "Bearer {token}"
 		const bearerPrefix = "Bearer "
 		if !strings.HasPrefix(authHeader, bearerPrefix) {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header must be in format: Bearer {token}",
-			})
-			c.Abort()
+			abortWithError(c, apperrors.ErrUnauthorized.WithMessage("Authorization header must be in format: Bearer {token}"))
 			return
 		}
 
 		// Extract the token
 		tokenString := strings.TrimPrefix(authHeader, bearerPrefix)
 		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Token cannot be empty",
-			})
-			c.Abort()
+			abortWithError(c, apperrors.ErrUnauthorized.WithMessage("Token cannot be empty"))
 			return
 		}
 
 		// Validate the JWT token
-		userID, err := utils.ValidateToken(tokenString)
+		claims, err := utils.ParseToken(tokenString)
 		if err != nil {
-			status := http.StatusUnauthorized
-			errorMsg := "Invalid token"
-			
+			appErr := apperrors.ErrInvalidToken
+
 			// Provide more specific error messages based on error type
 			if errors.Is(err, jwt.ErrTokenExpired) || strings.Contains(err.Error(), "token expired") {
-				errorMsg = "Token has expired"
+				appErr = apperrors.ErrTokenExpired
 			} else if strings.Contains(err.Error(), "signature") {
-				errorMsg = "Invalid token signature"
+				appErr = apperrors.ErrInvalidToken.WithMessage("Invalid token signature")
 			} else if strings.Contains(err.Error(), "parsing") {
-				errorMsg = "Token format is invalid"
+				appErr = apperrors.ErrInvalidToken.WithMessage("Token format is invalid")
 			}
-			
-			c.JSON(status, gin.H{
-				"error": errorMsg,
-			})
-			c.Abort()
+
+			abortWithError(c, appErr)
 			return
 		}
 
 		// Check if user exists in database
 		var user models.User
-		result := database.GetDB().First(&user, userID)
+		result := database.GetDB().WithContext(c.Request.Context()).First(&user, claims.UserID)
 		if result.Error != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "User not found or invalid token",
-			})
-			c.Abort()
+			abortWithError(c, apperrors.ErrUnauthorized.WithMessage("User not found or invalid token"))
 			return
 		}
 
-		// Set user ID in context for later use
-		c.Set("userID", userID)
-		c.Set("user", user)
+		// Tokens issued at login are only valid while their session exists
+		if claims.SessionID != 0 {
+			if err := services.NewSessionService().Authenticate(c.Request.Context(), claims.SessionID, user.ID); err != nil {
+				abortWithError(c, err)
+				return
+			}
+			c.Set("sessionID", claims.SessionID)
+		}
+
+		// Set the user in context for later use
+		setUser(c, &user)
 
 		// Continue to the next handler
 		c.Next()
 	}
 }
 
+// abortWithError aborts the request with the JSON error response for err
+func abortWithError(c *gin.Context, err error) {
+	appErr := apperrors.From(err)
+	c.AbortWithStatusJSON(appErr.Status, appErr.Response())
+}
+
+// setUser stores the authenticated user in context. Both ways of
+// authenticating go through here so that GetUser and GetUserID always find
+// the types they expect.
+func setUser(c *gin.Context, user *models.User) {
+	c.Set("userID", user.ID)
+	c.Set("user", user)
+}
+
 // GetUserID retrieves the current user ID from context
 func GetUserID(c *gin.Context) (uint, bool) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		return 0, false
-	}
-	return userID.(uint), true
+	value, _ := c.Get("userID")
+	userID, ok := value.(uint)
+	return userID, ok
+}
+
+// GetSessionID retrieves the login session of the token the request was
+// authenticated with. Requests authenticated with an API key, or with a token
+// not tied to a session, have none.
+func GetSessionID(c *gin.Context) (uint, bool) {
+	value, _ := c.Get("sessionID")
+	sessionID, ok := value.(uint)
+	return sessionID, ok
 }
 
-// GetUser retrieves the current user from context
+// GetUser retrieves the current user from context. The user is the one
+// loaded when authenticating, so handlers needn't query it again.
 func GetUser(c *gin.Context) (*models.User, bool) {
-	user, exists := c.Get("user")
-	if !exists {
-		return nil, false
-	}
-	return user.(*models.User), true
+	value, _ := c.Get("user")
+	user, ok := value.(*models.User)
+	return user, ok && user != nil
 }
\ No newline at end of file
@@ -0,0 +1,97 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"task-manager/internal/models"
+	"task-manager/pkg/database"
+	"task-manager/pkg/utils"
+)
+
+func TestGetUserReturnsAuthenticatedUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := database.InitTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Reset() })
+	if err := models.SetupModels(db); err != nil {
+		t.Fatal(err)
+	}
+
+	user := models.User{Username: "alice", Email: "alice@example.com", Password: "password123", Role: models.RoleUser}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	token, err := utils.GenerateToken(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, counter := database.WithQueryCounter(context.Background())
+	var (
+		got     *models.User
+		found   bool
+		stored  interface{}
+		queries int64
+	)
+	router := gin.New()
+	router.GET("/me", AuthMiddleware(), func(c *gin.Context) {
+		got, found = GetUser(c)
+		stored, _ = c.Get("user")
+		queries = counter.Count()
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil).WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("got status %d: %s", w.Code, w.Body.String())
+	}
+	if !found || got == nil {
+		t.Fatal("GetUser found no user")
+	}
+	if stored != got {
+		t.Error("GetUser didn't return the user AuthMiddleware stored")
+	}
+	if got.ID != user.ID || got.Username != user.Username {
+		t.Errorf("got user %d %q, want %d %q", got.ID, got.Username, user.ID, user.Username)
+	}
+	// The user is loaded once, when authenticating
+	if queries != 1 {
+		t.Errorf("authenticating and reading the user took %d queries, want 1", queries)
+	}
+}
+
+func TestSetUserGetUser(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	if _, ok := GetUser(c); ok {
+		t.Error("GetUser found a user before one was set")
+	}
+
+	user := &models.User{ID: 7, Username: "alice"}
+	setUser(c, user)
+
+	got, ok := GetUser(c)
+	if !ok || got != user {
+		t.Errorf("GetUser returned %v, %t; want the user set", got, ok)
+	}
+	if id, ok := GetUserID(c); !ok || id != user.ID {
+		t.Errorf("GetUserID returned %d, %t; want %d", id, ok, user.ID)
+	}
+
+	// A user stored by value, as AuthMiddleware once did, isn't returned
+	c.Set("user", *user)
+	if _, ok := GetUser(c); ok {
+		t.Error("GetUser accepted a models.User value")
+	}
+}
@@ -0,0 +1,87 @@
+package middlewares
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// secretFieldPattern matches the string value of JSON fields that hold
+// credentials, up to its closing quote or the end of a truncated body
+var secretFieldPattern = regexp.MustCompile(`(?i)("(?:password|token|key)"\s*:\s*)"(?:[^"\\]|\\.)*(?:"|$)`)
+
+// redactSecrets replaces the values of credential fields in a JSON body
+func redactSecrets(body string) string {
+	return secretFieldPattern.ReplaceAllString(body, `$1"[REDACTED]"`)
+}
+
+// formatLoggedBody redacts body for logging and marks it if it was cut short
+func formatLoggedBody(body []byte, truncated bool) string {
+	s := redactSecrets(string(body))
+	if truncated {
+		s += "...(truncated)"
+	}
+	return s
+}
+
+// captureRequestBody returns up to limit bytes of the request body and puts
+// them back in front of the rest, so handlers still read the whole body
+func captureRequestBody(c *gin.Context, limit int) string {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return ""
+	}
+
+	body := c.Request.Body
+	head, _ := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), body), body}
+
+	if len(head) > limit {
+		return formatLoggedBody(head[:limit], true)
+	}
+	return formatLoggedBody(head, false)
+}
+
+// bodyLogWriter copies up to limit bytes of the response body into buf as
+// it is written
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write implements gin.ResponseWriter
+func (w *bodyLogWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString implements gin.ResponseWriter
+func (w *bodyLogWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyLogWriter) capture(data []byte) {
+	room := w.limit - w.buf.Len()
+	if len(data) > room {
+		w.truncated = true
+		data = data[:room]
+	}
+	w.buf.Write(data)
+}
+
+// body returns the captured response body for logging. Compressed bodies
+// are left out since they aren't readable.
+func (w *bodyLogWriter) body() string {
+	if w.Header().Get("Content-Encoding") != "" {
+		return ""
+	}
+	return formatLoggedBody(w.buf.Bytes(), w.truncated)
+}
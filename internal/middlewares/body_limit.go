@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"task-manager/internal/apperrors"
+)
+
+// rawBodyKey stores the request body as received, before any size limit
+const rawBodyKey = "rawBody"
+
+// BodySizeLimitMiddleware rejects request bodies larger than maxBytes with
+// 413 Payload Too Large. Bodies that declare their size are rejected up
+// front; others fail when a handler reads past the limit. Applying the
+// middleware again on a route group replaces the limit, so endpoints that
+// legitimately take larger bodies can be given their own bound.
+func BodySizeLimitMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, ok := c.Get(rawBodyKey)
+		if !ok {
+			body = c.Request.Body
+			c.Set(rawBodyKey, body)
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			abortWithError(c, apperrors.ErrPayloadTooLarge.WithMessage(
+				fmt.Sprintf("Request body must not exceed %d bytes", maxBytes)))
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, body.(io.ReadCloser), maxBytes)
+		c.Next()
+	}
+}
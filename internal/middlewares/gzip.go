@@ -0,0 +1,157 @@
+package middlewares
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipMinLength is the smallest response body, in bytes, worth compressing
+const gzipMinLength = 1024
+
+// precompressedTypes lists content type prefixes that are already compressed
+var precompressedTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/pdf",
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// GzipMiddleware compresses responses larger than gzipMinLength for clients
+// that accept gzip. Bodies are buffered until the threshold is reached so
+// small responses are sent as-is.
+func GzipMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+
+		if c.Request.Method == "HEAD" || !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		writer := &gzipResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		defer writer.finish()
+
+		c.Next()
+	}
+}
+
+// gzipResponseWriter buffers the start of a response and switches to gzip
+// once the body is known to be large enough and compressible
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+	decided bool
+}
+
+// Write buffers data until the compression decision is made
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buf.Write(data)
+		if w.buf.Len() < gzipMinLength {
+			return len(data), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+
+	if w.gz != nil {
+		return w.gz.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString implements gin.ResponseWriter
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush sends buffered data immediately. Streamed responses can't be
+// measured up front, so they are sent uncompressed unless compression has
+// already started.
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide chooses whether to compress and writes out the buffered data
+func (w *gzipResponseWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+
+	if compress && header.Get("Content-Encoding") == "" && isCompressible(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+
+		w.gz = gzipWriterPool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+		_, err := w.gz.Write(w.buf.Bytes())
+		w.buf.Reset()
+		return err
+	}
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// finish sends any remaining buffered data and closes the gzip stream
+func (w *gzipResponseWriter) finish() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+		gzipWriterPool.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		q := strings.ReplaceAll(params, " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
+
+// isCompressible reports whether a response of the given content type
+// benefits from compression
+func isCompressible(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range precompressedTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// LegacyAPIMiddleware marks requests made through the unversioned /api
+// alias, so that handlers can keep its behaviour for clients written before
+// /api/v1
+func LegacyAPIMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("legacyAPI", true)
+		c.Next()
+	}
+}
+
+// IsLegacyAPI reports whether the request was made through the unversioned
+// /api alias
+func IsLegacyAPI(c *gin.Context) bool {
+	return c.GetBool("legacyAPI")
+}
@@ -3,21 +3,21 @@ package middlewares
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"task-manager/config"
+	"task-manager/pkg/logging"
 )
 
 // LogLevel represents the logging level
-type LogLevel string
+type LogLevel = logging.Level
 
 const (
-	DebugLevel LogLevel = "debug"
-	InfoLevel  LogLevel = "info"
-	WarnLevel  LogLevel = "warn"
-	ErrorLevel LogLevel = "error"
+	DebugLevel = logging.DebugLevel
+	InfoLevel  = logging.InfoLevel
+	WarnLevel  = logging.WarnLevel
+	ErrorLevel = logging.ErrorLevel
 )
 
 // RequestLogData represents structured log data for HTTP requests
@@ -35,6 +35,18 @@ type RequestLogData struct {
 	QueryParams string        `json:"query_params,omitempty"`
 	ReqSize     int           `json:"request_size,omitempty"`
 	RespSize    int           `json:"response_size"`
+	// Bodies are only captured at the debug level with LOG_BODIES enabled
+	RequestBody  string `json:"request_body,omitempty"`
+	ResponseBody string `json:"response_body,omitempty"`
+}
+
+// requestIDKey stores the ID of the request, see GetRequestID
+const requestIDKey = "requestID"
+
+// GetRequestID returns the ID the logger middleware gave the request, taken
+// from its X-Request-ID header if present
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
 }
 
 // LoggerMiddleware logs HTTP requests with enhanced details
@@ -49,6 +61,16 @@ func LoggerMiddleware() gin.HandlerFunc {
 			requestID = fmt.Sprintf("%d", time.Now().UnixNano())
 			c.Header("X-Request-ID", requestID)
 		}
+		c.Set(requestIDKey, requestID)
+
+		// Capture bodies for debugging if enabled
+		var requestBody string
+		var bodyWriter *bodyLogWriter
+		if logConfig := config.GetConfig().Logging; logConfig.Bodies && getConfiguredLogLevel() == DebugLevel {
+			requestBody = captureRequestBody(c, logConfig.BodyMaxSize)
+			bodyWriter = &bodyLogWriter{ResponseWriter: c.Writer, limit: logConfig.BodyMaxSize}
+			c.Writer = bodyWriter
+		}
 
 		// Process request
 		c.Next()
@@ -92,6 +114,12 @@ func LoggerMiddleware() gin.HandlerFunc {
 			logData.QueryParams = query
 		}
 
+		// Include captured bodies
+		if bodyWriter != nil {
+			logData.RequestBody = requestBody
+			logData.ResponseBody = bodyWriter.body()
+		}
+
 		// Include error if request failed
 		if len(c.Errors) > 0 {
 			logData.Error = c.Errors.String()
@@ -139,40 +167,7 @@ func LoggerMiddleware() gin.HandlerFunc {
 
 // Get configured log level from config or environment variable
 func getConfiguredLogLevel() LogLevel {
-	// First try to get from config (which also checks environment)
-	configLevel := config.GetConfig().Logging.Level
-	
-	switch configLevel {
-	case "debug":
-		return DebugLevel
-	case "info":
-		return InfoLevel
-	case "warn":
-		return WarnLevel
-	case "error":
-		return ErrorLevel
-	default:
-		// If config doesn't have a valid value, check environment directly
-		return getLogLevelFromEnv()
-	}
-}
-
-// Get log level directly from environment variable
-func getLogLevelFromEnv() LogLevel {
-	level := os.Getenv("LOG_LEVEL")
-	switch level {
-	case "debug":
-		return DebugLevel
-	case "info":
-		return InfoLevel
-	case "warn":
-		return WarnLevel
-	case "error":
-		return ErrorLevel
-	default:
-		// Default to info if not specified
-		return InfoLevel
-	}
+	return logging.ConfiguredLevel()
 }
 
 // Fallback simple logging when JSON fails
@@ -193,17 +188,17 @@ func simpleLog(statusCode int, level LogLevel, msg string) {
 
 // Log functions for different levels with color coding
 func debugLog(msg string) {
-	fmt.Printf("\033[37m[DEBUG] %s\033[0m\n", msg) // Light gray
+	logging.Log(logging.DebugLevel, msg)
 }
 
 func infoLog(msg string) {
-	fmt.Printf("\033[32m[INFO] %s\033[0m\n", msg) // Green
+	logging.Log(logging.InfoLevel, msg)
 }
 
 func warnLog(msg string) {
-	fmt.Printf("\033[33m[WARN] %s\033[0m\n", msg) // Yellow
+	logging.Log(logging.WarnLevel, msg)
 }
 
 func errorLog(msg string) {
-	fmt.Printf("\033[31m[ERROR] %s\033[0m\n", msg) // Red
-}
\ No newline at end of file
+	logging.Log(logging.ErrorLevel, msg)
+}
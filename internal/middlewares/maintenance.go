@@ -0,0 +1,49 @@
+package middlewares
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"task-manager/config"
+	"task-manager/internal/apperrors"
+)
+
+// maintenanceMode is checked on every request so the mode can be switched
+// without a restart
+var maintenanceMode atomic.Bool
+
+// SetMaintenanceMode turns maintenance mode on or off for this instance
+func SetMaintenanceMode(enabled bool) {
+	maintenanceMode.Store(enabled)
+}
+
+// InMaintenanceMode reports whether maintenance mode is on
+func InMaintenanceMode() bool {
+	return maintenanceMode.Load()
+}
+
+// MaintenanceMiddleware rejects requests that could change data with 503
+// Service Unavailable while maintenance mode is on. GET, HEAD and OPTIONS
+// requests are always let through. The Retry-After header tells clients
+// when to try again.
+func MaintenanceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !InMaintenanceMode() {
+			c.Next()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		retryAfter := config.GetConfig().App.MaintenanceRetryAfter
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		abortWithError(c, apperrors.ErrMaintenance)
+	}
+}
@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// QueryTokenMiddleware lets clients that can't set headers, such as the
+// browser's EventSource, send their JWT in the access_token query parameter.
+// It must run before AuthMiddleware. The parameter is removed from the URL
+// so the token doesn't end up in the request log.
+func QueryTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := c.Request.URL.Query()
+		if token := query.Get("access_token"); token != "" {
+			if c.GetHeader("Authorization") == "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+			query.Del("access_token")
+			c.Request.URL.RawQuery = query.Encode()
+		}
+		c.Next()
+	}
+}
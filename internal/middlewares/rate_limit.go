@@ -0,0 +1,112 @@
+package middlewares
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"task-manager/config"
+	"task-manager/internal/apperrors"
+)
+
+// rateLimitShards is how many independently locked parts the request counts
+// are split into, so that requests of different users rarely wait for each
+// other
+const rateLimitShards = 32
+
+// rateWindow counts a user's requests in the window starting at start
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// rateLimitShard holds the windows of the users whose ID falls into it
+type rateLimitShard struct {
+	mu      sync.Mutex
+	windows map[uint]*rateWindow
+	// swept is when windows that have ended were last dropped
+	swept time.Time
+}
+
+// userRateLimiter allows each user limit requests per fixed window
+type userRateLimiter struct {
+	limit  int
+	window time.Duration
+	shards [rateLimitShards]rateLimitShard
+}
+
+// newUserRateLimiter returns a limiter allowing limit requests per window
+func newUserRateLimiter(limit int, window time.Duration) *userRateLimiter {
+	l := &userRateLimiter{limit: limit, window: window}
+	for i := range l.shards {
+		l.shards[i].windows = make(map[uint]*rateWindow)
+	}
+	return l
+}
+
+// allow counts a request of the user at now and reports whether it is within
+// the limit, and if not how long until the user's window ends
+func (l *userRateLimiter) allow(userID uint, now time.Time) (bool, time.Duration) {
+	shard := &l.shards[userID%rateLimitShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	// Drop the windows of users who have stopped making requests, at most
+	// once per window so that it doesn't cost every request a scan
+	if now.Sub(shard.swept) >= l.window {
+		for id, w := range shard.windows {
+			if now.Sub(w.start) >= l.window {
+				delete(shard.windows, id)
+			}
+		}
+		shard.swept = now
+	}
+
+	w, ok := shard.windows[userID]
+	if !ok || now.Sub(w.start) >= l.window {
+		w = &rateWindow{start: now}
+		shard.windows[userID] = w
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// UserRateLimitMiddleware limits how many requests each authenticated user
+// may make, as configured with USER_RATE_LIMIT and USER_RATE_LIMIT_WINDOW.
+// It must run after AuthMiddleware. Requests over the limit get 429 Too
+// Many Requests with a Retry-After header saying when the window ends.
+// Requests are counted per instance; the same handler should be used on
+// every route group so that a user has one count.
+func UserRateLimitMiddleware() gin.HandlerFunc {
+	cfg := config.GetConfig().Security
+	if cfg.UserRateLimit <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	limiter := newUserRateLimiter(cfg.UserRateLimit, cfg.UserRateLimitWindow)
+	return func(c *gin.Context) {
+		userID, ok := GetUserID(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		allowed, retryAfter := limiter.allow(userID, time.Now())
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			abortWithError(c, apperrors.ErrRateLimited.WithMessage(fmt.Sprintf("Too many requests; try again in %d seconds", seconds)))
+			return
+		}
+		c.Next()
+	}
+}
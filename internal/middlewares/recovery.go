@@ -0,0 +1,102 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"task-manager/config"
+	"task-manager/internal/apperrors"
+)
+
+// PanicLogData represents the structured log entry of a recovered panic
+type PanicLogData struct {
+	Timestamp string `json:"timestamp"`
+	Event     string `json:"event"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	RequestID string `json:"request_id,omitempty"`
+	Panic     string `json:"panic"`
+	Stack     string `json:"stack"`
+}
+
+// RecoveryMiddleware recovers from panics in later handlers, logs them with
+// their stack as a structured error and responds with 500 Internal Server
+// Error. Outside production the response message includes the panic value;
+// the stack is only ever logged. It must come after LoggerMiddleware so the
+// request ID is known and the failed request is logged too.
+func RecoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			logPanic(c, recovered, debug.Stack())
+			_ = c.Error(fmt.Errorf("panic: %v", recovered))
+
+			// The client is gone or the response has started, so there is
+			// nothing left to tell it
+			if isBrokenPipe(recovered) || c.Writer.Written() {
+				c.Abort()
+				return
+			}
+
+			appErr := apperrors.ErrInternal
+			if config.GetConfig().App.Env != "production" {
+				appErr = appErr.WithMessage(fmt.Sprintf("Internal server error: panic: %v", recovered))
+			}
+			c.AbortWithStatusJSON(appErr.Status, appErr.Response())
+		}()
+
+		c.Next()
+	}
+}
+
+// logPanic writes a recovered panic to the error log
+func logPanic(c *gin.Context, recovered interface{}, stack []byte) {
+	logData := PanicLogData{
+		Timestamp: time.Now().Format(time.RFC3339),
+		Event:     "panic",
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		RequestID: GetRequestID(c),
+		Panic:     fmt.Sprint(recovered),
+		Stack:     string(stack),
+	}
+
+	logJSON, err := json.Marshal(logData)
+	if err != nil {
+		errorLog(fmt.Sprintf("panic recovered: %s %s: %v\n%s", logData.Method, logData.Path, recovered, stack))
+		return
+	}
+	errorLog(string(logJSON))
+}
+
+// isBrokenPipe reports whether a panic was caused by the client closing the
+// connection while the response was written
+func isBrokenPipe(recovered interface{}) bool {
+	err, ok := recovered.(error)
+	if !ok {
+		return false
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var syscallErr *os.SyscallError
+	if !errors.As(opErr, &syscallErr) {
+		return false
+	}
+	msg := strings.ToLower(syscallErr.Error())
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
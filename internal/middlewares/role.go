@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"task-manager/internal/apperrors"
+	"task-manager/internal/models"
+)
+
+// RequireRole allows the request through only if the authenticated user has
+// one of the given roles. It must run after AuthMiddleware.
+func RequireRole(roles ...models.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := GetUser(c)
+		if !exists {
+			abortWithError(c, apperrors.ErrUnauthorized)
+			return
+		}
+
+		for _, role := range roles {
+			if user.Role == role {
+				c.Next()
+				return
+			}
+		}
+
+		abortWithError(c, apperrors.ErrForbidden.WithMessage("You do not have permission to perform this action"))
+	}
+}
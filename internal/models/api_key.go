@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// APIKey represents a long-lived credential a user can create for
+// programmatic access. Only a hash of the key is stored.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	Prefix     string     `gorm:"size:16;not null" json:"prefix"`
+	KeyHash    string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	User       User       `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// TableName specifies the table name for the APIKey model
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// MarshalJSON renders the key's timestamps in the configured time zone and
+// format
+func (k APIKey) MarshalJSON() ([]byte, error) {
+	type apiKey APIKey
+	return json.Marshal(struct {
+		apiKey
+		LastUsedAt *Timestamp `json:"last_used_at"`
+		ExpiresAt  *Timestamp `json:"expires_at"`
+		CreatedAt  Timestamp  `json:"created_at"`
+	}{
+		apiKey:     apiKey(k),
+		LastUsedAt: NewTimestampPtr(k.LastUsedAt),
+		ExpiresAt:  NewTimestampPtr(k.ExpiresAt),
+		CreatedAt:  NewTimestamp(k.CreatedAt),
+	})
+}
+
+// IsExpired reports whether the key has passed its expiry time
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// IdempotencyKey records the task created for a client-supplied
+// Idempotency-Key so that retried requests return the original result
+type IdempotencyKey struct {
+	ID          uint      `gorm:"primaryKey"`
+	UserID      uint      `gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Key         string    `gorm:"size:255;not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	RequestHash string    `gorm:"size:64;not null"`
+	TaskID      uint      `gorm:"not null"`
+	CreatedAt   time.Time `gorm:"index"`
+}
+
+// TableName specifies the table name for the IdempotencyKey model
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
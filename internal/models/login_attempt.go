@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// LoginAttempt tracks recent failed logins for an email address so the
+// account can be locked after too many consecutive failures
+type LoginAttempt struct {
+	ID          uint      `gorm:"primaryKey"`
+	Email       string    `gorm:"size:100;not null;uniqueIndex"`
+	Failures    int       `gorm:"not null;default:0"`
+	WindowStart time.Time `gorm:"not null"`
+	LockedUntil *time.Time
+	UpdatedAt   time.Time
+}
+
+// TableName specifies the table name for the LoginAttempt model
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// IsLocked reports whether the account is locked at the given time
+func (a *LoginAttempt) IsLocked(now time.Time) bool {
+	return a.LockedUntil != nil && now.Before(*a.LockedUntil)
+}
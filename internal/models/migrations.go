@@ -0,0 +1,453 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// MigrationsTable is the table recording which migrations have been applied
+const MigrationsTable = "schema_migrations"
+
+// taskFulltextIndex is the MySQL FULLTEXT index on the title and description
+// of tasks
+const taskFulltextIndex = "idx_tasks_fulltext"
+
+// NewMigrator returns a migrator for the application's versioned schema
+func NewMigrator(db *gorm.DB) *gormigrate.Gormigrate {
+	return gormigrate.New(db, &gormigrate.Options{
+		TableName:    MigrationsTable,
+		IDColumnName: "id",
+		IDColumnSize: 255,
+	}, migrations())
+}
+
+// migrations returns the ordered list of schema migrations. Each migration
+// declares its own snapshot of the tables it touches so that it keeps working
+// as the models evolve. Never edit or reorder a migration once released;
+// append a new one instead.
+func migrations() []*gormigrate.Migration {
+	return []*gormigrate.Migration{
+		{
+			ID: "0001_create_users_and_tasks",
+			Migrate: func(tx *gorm.DB) error {
+				type User struct {
+					ID        uint   `gorm:"primaryKey"`
+					Username  string `gorm:"size:100;not null;unique"`
+					Email     string `gorm:"size:100;not null;unique"`
+					Password  string `gorm:"size:255;not null"`
+					CreatedAt time.Time
+					UpdatedAt time.Time
+					DeletedAt gorm.DeletedAt `gorm:"index"`
+				}
+				type Task struct {
+					ID          uint   `gorm:"primaryKey"`
+					UserID      uint   `gorm:"not null"`
+					Title       string `gorm:"size:200;not null"`
+					Description string `gorm:"type:text"`
+					DueDate     *time.Time
+					Priority    Priority `gorm:"size:20;default:'medium'"`
+					Status      Status   `gorm:"size:20;default:'todo'"`
+					CreatedAt   time.Time
+					UpdatedAt   time.Time
+					DeletedAt   gorm.DeletedAt `gorm:"index"`
+					User        User           `gorm:"foreignKey:UserID"`
+				}
+				return tx.AutoMigrate(&User{}, &Task{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("tasks", "users")
+			},
+		},
+		{
+			ID: "0002_add_task_reminders",
+			Migrate: func(tx *gorm.DB) error {
+				type Task struct {
+					RemindAt   *time.Time `gorm:"index"`
+					RemindedAt *time.Time
+				}
+				if err := tx.Migrator().AddColumn(&Task{}, "RemindAt"); err != nil {
+					return err
+				}
+				if err := tx.Migrator().CreateIndex(&Task{}, "RemindAt"); err != nil {
+					return err
+				}
+				return tx.Migrator().AddColumn(&Task{}, "RemindedAt")
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Task struct {
+					RemindAt   *time.Time `gorm:"index"`
+					RemindedAt *time.Time
+				}
+				if err := tx.Migrator().DropIndex(&Task{}, "RemindAt"); err != nil {
+					return err
+				}
+				if err := tx.Migrator().DropColumn(&Task{}, "RemindAt"); err != nil {
+					return err
+				}
+				return tx.Migrator().DropColumn(&Task{}, "RemindedAt")
+			},
+		},
+		{
+			ID: "0003_create_api_keys",
+			Migrate: func(tx *gorm.DB) error {
+				type User struct {
+					ID uint `gorm:"primaryKey"`
+				}
+				type APIKey struct {
+					ID         uint   `gorm:"primaryKey"`
+					UserID     uint   `gorm:"not null;index"`
+					Name       string `gorm:"size:100;not null"`
+					Prefix     string `gorm:"size:16;not null"`
+					KeyHash    string `gorm:"size:64;not null;uniqueIndex"`
+					LastUsedAt *time.Time
+					ExpiresAt  *time.Time
+					CreatedAt  time.Time
+					User       User `gorm:"foreignKey:UserID"`
+				}
+				return tx.Migrator().CreateTable(&APIKey{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("api_keys")
+			},
+		},
+		{
+			ID: "0004_add_task_version",
+			Migrate: func(tx *gorm.DB) error {
+				type Task struct {
+					Version int `gorm:"not null;default:1"`
+				}
+				return tx.Migrator().AddColumn(&Task{}, "Version")
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Task struct {
+					Version int `gorm:"not null;default:1"`
+				}
+				return tx.Migrator().DropColumn(&Task{}, "Version")
+			},
+		},
+		{
+			ID: "0005_create_idempotency_keys",
+			Migrate: func(tx *gorm.DB) error {
+				type IdempotencyKey struct {
+					ID          uint      `gorm:"primaryKey"`
+					UserID      uint      `gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key"`
+					Key         string    `gorm:"size:255;not null;uniqueIndex:idx_idempotency_keys_user_key"`
+					RequestHash string    `gorm:"size:64;not null"`
+					TaskID      uint      `gorm:"not null"`
+					CreatedAt   time.Time `gorm:"index"`
+				}
+				return tx.Migrator().CreateTable(&IdempotencyKey{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("idempotency_keys")
+			},
+		},
+		{
+			ID: "0006_add_task_assignee",
+			Migrate: func(tx *gorm.DB) error {
+				type Task struct {
+					AssigneeID *uint `gorm:"index"`
+				}
+				if err := tx.Migrator().AddColumn(&Task{}, "AssigneeID"); err != nil {
+					return err
+				}
+				return tx.Migrator().CreateIndex(&Task{}, "AssigneeID")
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Task struct {
+					AssigneeID *uint `gorm:"index"`
+				}
+				if err := tx.Migrator().DropIndex(&Task{}, "AssigneeID"); err != nil {
+					return err
+				}
+				return tx.Migrator().DropColumn(&Task{}, "AssigneeID")
+			},
+		},
+		{
+			ID: "0007_create_task_activities",
+			Migrate: func(tx *gorm.DB) error {
+				type TaskActivity struct {
+					ID        uint   `gorm:"primaryKey"`
+					TaskID    uint   `gorm:"not null;index"`
+					UserID    uint   `gorm:"not null"`
+					Action    string `gorm:"size:50;not null"`
+					OldValue  string `gorm:"type:text"`
+					NewValue  string `gorm:"type:text"`
+					CreatedAt time.Time
+				}
+				return tx.Migrator().CreateTable(&TaskActivity{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("task_activities")
+			},
+		},
+		{
+			ID: "0008_create_login_attempts",
+			Migrate: func(tx *gorm.DB) error {
+				type LoginAttempt struct {
+					ID          uint      `gorm:"primaryKey"`
+					Email       string    `gorm:"size:100;not null;uniqueIndex"`
+					Failures    int       `gorm:"not null;default:0"`
+					WindowStart time.Time `gorm:"not null"`
+					LockedUntil *time.Time
+					UpdatedAt   time.Time
+				}
+				return tx.Migrator().CreateTable(&LoginAttempt{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("login_attempts")
+			},
+		},
+		{
+			ID: "0009_add_user_role",
+			Migrate: func(tx *gorm.DB) error {
+				type User struct {
+					Role string `gorm:"size:20;not null;default:'user'"`
+				}
+				return tx.Migrator().AddColumn(&User{}, "Role")
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type User struct {
+					Role string `gorm:"size:20;not null;default:'user'"`
+				}
+				return tx.Migrator().DropColumn(&User{}, "Role")
+			},
+		},
+		{
+			ID: "0010_create_task_dependencies",
+			Migrate: func(tx *gorm.DB) error {
+				type TaskDependency struct {
+					ID          uint `gorm:"primaryKey"`
+					TaskID      uint `gorm:"not null;uniqueIndex:idx_task_dependency"`
+					DependsOnID uint `gorm:"not null;uniqueIndex:idx_task_dependency;index"`
+					CreatedAt   time.Time
+				}
+				return tx.Migrator().CreateTable(&TaskDependency{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("task_dependencies")
+			},
+		},
+		{
+			// Only MySQL supports FULLTEXT indexes; fulltext search falls
+			// back to LIKE on other drivers
+			ID: "0011_add_task_fulltext_index",
+			Migrate: func(tx *gorm.DB) error {
+				if tx.Dialector.Name() != "mysql" {
+					return nil
+				}
+				return tx.Exec("CREATE FULLTEXT INDEX " + taskFulltextIndex + " ON tasks (title, description)").Error
+			},
+			Rollback: func(tx *gorm.DB) error {
+				if tx.Dialector.Name() != "mysql" {
+					return nil
+				}
+				return tx.Exec("DROP INDEX " + taskFulltextIndex + " ON tasks").Error
+			},
+		},
+		{
+			ID: "0012_add_task_position",
+			Migrate: func(tx *gorm.DB) error {
+				type Task struct {
+					Position int `gorm:"not null;default:0;index"`
+				}
+				if err := tx.Migrator().AddColumn(&Task{}, "Position"); err != nil {
+					return err
+				}
+				if err := tx.Migrator().CreateIndex(&Task{}, "Position"); err != nil {
+					return err
+				}
+				// Keep the creation order of existing tasks
+				return tx.Exec("UPDATE tasks SET position = id").Error
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Task struct {
+					Position int `gorm:"not null;default:0;index"`
+				}
+				if err := tx.Migrator().DropIndex(&Task{}, "Position"); err != nil {
+					return err
+				}
+				return tx.Migrator().DropColumn(&Task{}, "Position")
+			},
+		},
+		{
+			ID: "0013_add_task_completed_at",
+			Migrate: func(tx *gorm.DB) error {
+				type Task struct {
+					CompletedAt *time.Time `gorm:"index"`
+				}
+				if err := tx.Migrator().AddColumn(&Task{}, "CompletedAt"); err != nil {
+					return err
+				}
+				if err := tx.Migrator().CreateIndex(&Task{}, "CompletedAt"); err != nil {
+					return err
+				}
+				// The completion time of existing tasks isn't known; the last
+				// update is the best guess
+				return tx.Exec("UPDATE tasks SET completed_at = updated_at WHERE status = ?", "completed").Error
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Task struct {
+					CompletedAt *time.Time `gorm:"index"`
+				}
+				if err := tx.Migrator().DropIndex(&Task{}, "CompletedAt"); err != nil {
+					return err
+				}
+				return tx.Migrator().DropColumn(&Task{}, "CompletedAt")
+			},
+		},
+		{
+			// Priority and status used to be ENUM columns on MySQL. They are
+			// validated by the application now, like on the other drivers.
+			ID: "0014_task_enums_to_varchar",
+			Migrate: func(tx *gorm.DB) error {
+				if tx.Dialector.Name() != "mysql" {
+					return nil
+				}
+				return tx.Exec("ALTER TABLE tasks" +
+					" MODIFY priority varchar(20) DEFAULT 'medium'," +
+					" MODIFY status varchar(20) DEFAULT 'todo'").Error
+			},
+			Rollback: func(tx *gorm.DB) error {
+				if tx.Dialector.Name() != "mysql" {
+					return nil
+				}
+				return tx.Exec("ALTER TABLE tasks" +
+					" MODIFY priority enum('low','medium','high') DEFAULT 'medium'," +
+					" MODIFY status enum('todo','in_progress','completed') DEFAULT 'todo'").Error
+			},
+		},
+		{
+			ID: "0015_add_task_pinned",
+			Migrate: func(tx *gorm.DB) error {
+				type Task struct {
+					Pinned bool `gorm:"not null;default:false"`
+				}
+				return tx.Migrator().AddColumn(&Task{}, "Pinned")
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Task struct {
+					Pinned bool `gorm:"not null;default:false"`
+				}
+				return tx.Migrator().DropColumn(&Task{}, "Pinned")
+			},
+		},
+		{
+			ID: "0016_add_task_archived_at",
+			Migrate: func(tx *gorm.DB) error {
+				type Task struct {
+					ArchivedAt *time.Time `gorm:"index"`
+				}
+				if err := tx.Migrator().AddColumn(&Task{}, "ArchivedAt"); err != nil {
+					return err
+				}
+				return tx.Migrator().CreateIndex(&Task{}, "ArchivedAt")
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Task struct {
+					ArchivedAt *time.Time `gorm:"index"`
+				}
+				if err := tx.Migrator().DropIndex(&Task{}, "ArchivedAt"); err != nil {
+					return err
+				}
+				return tx.Migrator().DropColumn(&Task{}, "ArchivedAt")
+			},
+		},
+		{
+			ID: "0017_add_task_color",
+			Migrate: func(tx *gorm.DB) error {
+				type Task struct {
+					Color string `gorm:"size:7;not null;default:''"`
+				}
+				return tx.Migrator().AddColumn(&Task{}, "Color")
+			},
+			Rollback: func(tx *gorm.DB) error {
+				type Task struct {
+					Color string `gorm:"size:7;not null;default:''"`
+				}
+				return tx.Migrator().DropColumn(&Task{}, "Color")
+			},
+		},
+		{
+			ID: "0018_create_sessions",
+			Migrate: func(tx *gorm.DB) error {
+				type User struct {
+					ID uint `gorm:"primaryKey"`
+				}
+				type Session struct {
+					ID         uint   `gorm:"primaryKey"`
+					UserID     uint   `gorm:"not null;index"`
+					UserAgent  string `gorm:"size:255"`
+					IP         string `gorm:"size:45"`
+					LastUsedAt *time.Time
+					ExpiresAt  time.Time `gorm:"not null;index"`
+					CreatedAt  time.Time
+					User       User `gorm:"foreignKey:UserID"`
+				}
+				return tx.Migrator().CreateTable(&Session{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("sessions")
+			},
+		},
+		{
+			ID: "0019_create_task_collaborators",
+			Migrate: func(tx *gorm.DB) error {
+				type TaskCollaborator struct {
+					ID        uint   `gorm:"primaryKey"`
+					TaskID    uint   `gorm:"not null;uniqueIndex:idx_task_collaborator"`
+					UserID    uint   `gorm:"not null;uniqueIndex:idx_task_collaborator;index"`
+					Role      string `gorm:"size:20;not null"`
+					CreatedAt time.Time
+					UpdatedAt time.Time
+				}
+				return tx.Migrator().CreateTable(&TaskCollaborator{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable("task_collaborators")
+			},
+		},
+	}
+}
+
+// PendingMigrationsError reports migrations known to this build that have not
+// been applied to the database yet
+type PendingMigrationsError struct {
+	IDs []string
+}
+
+func (e *PendingMigrationsError) Error() string {
+	return fmt.Sprintf("%d pending migration(s): %s", len(e.IDs), strings.Join(e.IDs, ", "))
+}
+
+// VerifySchema checks that every migration this build expects has been
+// applied, returning a *PendingMigrationsError listing those that haven't.
+// Migrations recorded in the database but unknown to this build are ignored,
+// so an older build keeps reporting ready while a newer one rolls out.
+func VerifySchema(db *gorm.DB) error {
+	applied := make(map[string]bool)
+	if db.Migrator().HasTable(MigrationsTable) {
+		var ids []string
+		if err := db.Table(MigrationsTable).Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("failed to read applied migrations: %v", err)
+		}
+		for _, id := range ids {
+			applied[id] = true
+		}
+	}
+
+	var pending []string
+	for _, m := range migrations() {
+		if !applied[m.ID] {
+			pending = append(pending, m.ID)
+		}
+	}
+	if len(pending) > 0 {
+		return &PendingMigrationsError{IDs: pending}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Session records a login. Tokens issued at login carry the session's ID and
+// are only accepted while the session exists, so deleting it logs that
+// device out.
+type Session struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	UserAgent  string     `gorm:"size:255" json:"user_agent"`
+	IP         string     `gorm:"size:45" json:"ip"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Current marks the session of the token the request was made with
+	Current bool `gorm:"-" json:"current"`
+}
+
+// TableName specifies the table name for the Session model
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// MarshalJSON renders the session's timestamps in the configured time zone
+// and format
+func (s Session) MarshalJSON() ([]byte, error) {
+	type session Session
+	return json.Marshal(struct {
+		session
+		LastUsedAt *Timestamp `json:"last_used_at"`
+		ExpiresAt  Timestamp  `json:"expires_at"`
+		CreatedAt  Timestamp  `json:"created_at"`
+	}{
+		session:    session(s),
+		LastUsedAt: NewTimestampPtr(s.LastUsedAt),
+		ExpiresAt:  NewTimestamp(s.ExpiresAt),
+		CreatedAt:  NewTimestamp(s.CreatedAt),
+	})
+}
@@ -5,14 +5,13 @@ import (
 	"gorm.io/gorm"
 )
 
-// SetupModels initializes database tables based on the defined models
+// SetupModels brings the database schema up to date by applying any
+// pending versioned migrations
 func SetupModels(db *gorm.DB) error {
-	// Auto migrate will create or update tables according to model structures
-	err := db.AutoMigrate(&User{}, &Task{})
-	if err != nil {
-		return fmt.Errorf("failed to auto migrate models: %v", err)
+	if err := NewMigrator(db).Migrate(); err != nil {
+		return fmt.Errorf("failed to run database migrations: %v", err)
 	}
 
 	fmt.Println("Database migration completed successfully")
 	return nil
-}
\ No newline at end of file
+}
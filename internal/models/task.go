@@ -1,6 +1,12 @@
 package models
 
 import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -24,22 +30,222 @@ const (
 	StatusCompleted  Status = "completed"
 )
 
-// Task represents the task model in the database
+// Priorities and statuses are stored as plain strings on every database.
+// Their Value methods refuse to write anything but the defined values.
+
+// IsValid reports whether p is one of the defined priority levels
+func (p Priority) IsValid() bool {
+	switch p {
+	case PriorityLow, PriorityMedium, PriorityHigh:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether s is one of the defined status options
+func (s Status) IsValid() bool {
+	switch s {
+	case StatusTodo, StatusInProgress, StatusCompleted:
+		return true
+	}
+	return false
+}
+
+// priorityValues and statusValues list the valid values for error messages
+var (
+	priorityValues = []string{string(PriorityLow), string(PriorityMedium), string(PriorityHigh)}
+	statusValues   = []string{string(StatusTodo), string(StatusInProgress), string(StatusCompleted)}
+)
+
+// InvalidValueError is returned when decoding a priority or status that
+// isn't one of the defined values
+type InvalidValueError struct {
+	// Field is the JSON name of the field, e.g. "priority"
+	Field   string
+	Value   string
+	Allowed []string
+}
+
+// Error implements the error interface
+func (e *InvalidValueError) Error() string {
+	return fmt.Sprintf("invalid %s %q: %s", e.Field, e.Value, e.Reason())
+}
+
+// Reason describes the accepted values, e.g. "must be one of low, medium, high"
+func (e *InvalidValueError) Reason() string {
+	return "must be one of " + strings.Join(e.Allowed, ", ")
+}
+
+// UnmarshalJSON implements json.Unmarshaler, rejecting unknown priorities.
+// An empty string is accepted and means the default or current priority.
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	value, err := unmarshalEnum(data, "priority", priorityValues)
+	if err != nil {
+		return err
+	}
+	if value != nil {
+		*p = Priority(*value)
+	}
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, rejecting unknown statuses. An
+// empty string is accepted and left to the request's validation.
+func (s *Status) UnmarshalJSON(data []byte) error {
+	value, err := unmarshalEnum(data, "status", statusValues)
+	if err != nil {
+		return err
+	}
+	if value != nil {
+		*s = Status(*value)
+	}
+	return nil
+}
+
+// unmarshalEnum decodes a JSON string that must be empty or one of allowed.
+// It returns nil for a JSON null, which leaves the target unchanged.
+func unmarshalEnum(data []byte, field string, allowed []string) (*string, error) {
+	if bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	if value != "" && !slices.Contains(allowed, value) {
+		return nil, &InvalidValueError{Field: field, Value: value, Allowed: allowed}
+	}
+	return &value, nil
+}
+
+// Scan implements sql.Scanner, refusing to load an unknown priority from a
+// corrupt row
+func (p *Priority) Scan(src interface{}) error {
+	value, err := scanEnum(src, "priority", priorityValues)
+	if err != nil {
+		return err
+	}
+	*p = Priority(value)
+	return nil
+}
+
+// Scan implements sql.Scanner, refusing to load an unknown status from a
+// corrupt row
+func (s *Status) Scan(src interface{}) error {
+	value, err := scanEnum(src, "status", statusValues)
+	if err != nil {
+		return err
+	}
+	*s = Status(value)
+	return nil
+}
+
+// Value implements driver.Valuer, refusing to write an unknown priority
+// whichever query writes it
+func (p Priority) Value() (driver.Value, error) {
+	return valueEnum(string(p), "priority", priorityValues)
+}
+
+// Value implements driver.Valuer, refusing to write an unknown status
+// whichever query writes it
+func (s Status) Value() (driver.Value, error) {
+	return valueEnum(string(s), "status", statusValues)
+}
+
+// valueEnum returns value for the database if it is empty or one of allowed
+func valueEnum(value string, field string, allowed []string) (driver.Value, error) {
+	if value != "" && !slices.Contains(allowed, value) {
+		return nil, &InvalidValueError{Field: field, Value: value, Allowed: allowed}
+	}
+	return value, nil
+}
+
+// scanEnum reads a database value that must be NULL, empty or one of
+// allowed. NULL is read as an empty string.
+func scanEnum(src interface{}, field string, allowed []string) (string, error) {
+	var value string
+	switch v := src.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return "", fmt.Errorf("cannot read %s from %T", field, src)
+	}
+	if value != "" && !slices.Contains(allowed, value) {
+		return "", &InvalidValueError{Field: field, Value: value, Allowed: allowed}
+	}
+	return value, nil
+}
+
+// Task represents the task model in the database. User and Assignee are
+// only loaded when asked for.
 type Task struct {
 	ID          uint           `gorm:"primaryKey" json:"id"`
 	UserID      uint           `gorm:"not null" json:"user_id"`
+	AssigneeID  *uint          `gorm:"index" json:"assignee_id"`
 	Title       string         `gorm:"size:200;not null" json:"title"`
 	Description string         `gorm:"type:text" json:"description"`
 	DueDate     *time.Time     `json:"due_date"`
-	Priority    Priority       `gorm:"type:enum('low','medium','high');default:'medium'" json:"priority"`
-	Status      Status         `gorm:"type:enum('todo','in_progress','completed');default:'todo'" json:"status"`
+	RemindAt    *time.Time     `gorm:"index" json:"remind_at"`
+	RemindedAt  *time.Time     `json:"reminded_at"`
+	Priority    Priority       `gorm:"size:20;default:'medium'" json:"priority"`
+	Status      Status         `gorm:"size:20;default:'todo'" json:"status"`
+	CompletedAt *time.Time     `gorm:"index" json:"completed_at"`
+	Color       string         `gorm:"size:7;not null;default:''" json:"color"`
+	Pinned      bool           `gorm:"not null;default:false" json:"pinned"`
+	ArchivedAt  *time.Time     `gorm:"index" json:"archived_at"`
+	Position    int            `gorm:"not null;default:0;index" json:"position"`
+	Version     int            `gorm:"not null;default:1" json:"version"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
-	User        User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	User        *User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Assignee    *User          `gorm:"foreignKey:AssigneeID" json:"assignee,omitempty"`
 }
 
 // TableName specifies the table name for the Task model
 func (Task) TableName() string {
 	return "tasks"
-}
\ No newline at end of file
+}
+
+// BeforeSave is a GORM hook that refuses to write an unknown priority or
+// status, whichever service path the write comes from. Empty values are let
+// through: updates of single columns run the hook on an empty Task, and on
+// create the column defaults apply.
+func (t *Task) BeforeSave(tx *gorm.DB) error {
+	if t.Priority != "" && !t.Priority.IsValid() {
+		return &InvalidValueError{Field: "priority", Value: string(t.Priority), Allowed: priorityValues}
+	}
+	if t.Status != "" && !t.Status.IsValid() {
+		return &InvalidValueError{Field: "status", Value: string(t.Status), Allowed: statusValues}
+	}
+	return nil
+}
+
+// MarshalJSON renders the task's timestamps in the configured time zone and
+// format
+func (t Task) MarshalJSON() ([]byte, error) {
+	type task Task
+	return json.Marshal(struct {
+		task
+		DueDate     *Timestamp `json:"due_date"`
+		RemindAt    *Timestamp `json:"remind_at"`
+		RemindedAt  *Timestamp `json:"reminded_at"`
+		CompletedAt *Timestamp `json:"completed_at"`
+		ArchivedAt  *Timestamp `json:"archived_at"`
+		CreatedAt   Timestamp  `json:"created_at"`
+		UpdatedAt   Timestamp  `json:"updated_at"`
+	}{
+		task:        task(t),
+		DueDate:     NewTimestampPtr(t.DueDate),
+		RemindAt:    NewTimestampPtr(t.RemindAt),
+		RemindedAt:  NewTimestampPtr(t.RemindedAt),
+		CompletedAt: NewTimestampPtr(t.CompletedAt),
+		ArchivedAt:  NewTimestampPtr(t.ArchivedAt),
+		CreatedAt:   NewTimestamp(t.CreatedAt),
+		UpdatedAt:   NewTimestamp(t.UpdatedAt),
+	})
+}
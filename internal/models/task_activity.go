@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ActivityAction describes what changed in a task activity entry
+type ActivityAction string
+
+const (
+	// Activity actions
+	ActivityTitleChanged       ActivityAction = "title_changed"
+	ActivityDescriptionChanged ActivityAction = "description_changed"
+	ActivityDueDateChanged     ActivityAction = "due_date_changed"
+	ActivityReminderChanged    ActivityAction = "remind_at_changed"
+	ActivityPriorityChanged    ActivityAction = "priority_changed"
+	ActivityStatusChanged      ActivityAction = "status_changed"
+	ActivityAssigneeChanged    ActivityAction = "assignee_changed"
+	ActivityColorChanged       ActivityAction = "color_changed"
+	ActivityPinnedChanged      ActivityAction = "pinned_changed"
+	ActivityArchivedChanged    ActivityAction = "archived_at_changed"
+	ActivityDeleted            ActivityAction = "deleted"
+)
+
+// TaskActivity records a single change made to a task and who made it
+type TaskActivity struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	TaskID    uint           `gorm:"not null;index" json:"task_id"`
+	UserID    uint           `gorm:"not null" json:"user_id"`
+	Action    ActivityAction `gorm:"size:50;not null" json:"action"`
+	OldValue  string         `gorm:"type:text" json:"old_value"`
+	NewValue  string         `gorm:"type:text" json:"new_value"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// TableName specifies the table name for the TaskActivity model
+func (TaskActivity) TableName() string {
+	return "task_activities"
+}
+
+// MarshalJSON renders the entry's timestamp in the configured time zone and
+// format
+func (a TaskActivity) MarshalJSON() ([]byte, error) {
+	type taskActivity TaskActivity
+	return json.Marshal(struct {
+		taskActivity
+		CreatedAt Timestamp `json:"created_at"`
+	}{
+		taskActivity: taskActivity(a),
+		CreatedAt:    NewTimestamp(a.CreatedAt),
+	})
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CollaboratorRole determines what a user a task is shared with may do
+type CollaboratorRole string
+
+const (
+	// CollaboratorViewer can read the task
+	CollaboratorViewer CollaboratorRole = "viewer"
+	// CollaboratorEditor can also change the task's fields and status
+	CollaboratorEditor CollaboratorRole = "editor"
+)
+
+// IsValid reports whether r is one of the defined collaborator roles
+func (r CollaboratorRole) IsValid() bool {
+	switch r {
+	case CollaboratorViewer, CollaboratorEditor:
+		return true
+	}
+	return false
+}
+
+// TaskCollaborator records that a task's owner shared it with another user
+type TaskCollaborator struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	TaskID    uint             `gorm:"not null;uniqueIndex:idx_task_collaborator" json:"task_id"`
+	UserID    uint             `gorm:"not null;uniqueIndex:idx_task_collaborator;index" json:"user_id"`
+	Role      CollaboratorRole `gorm:"size:20;not null" json:"role"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	User      *User            `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// TableName specifies the table name for the TaskCollaborator model
+func (TaskCollaborator) TableName() string {
+	return "task_collaborators"
+}
+
+// MarshalJSON renders the collaborator's timestamps in the configured time
+// zone and format
+func (c TaskCollaborator) MarshalJSON() ([]byte, error) {
+	type taskCollaborator TaskCollaborator
+	return json.Marshal(struct {
+		taskCollaborator
+		CreatedAt Timestamp `json:"created_at"`
+		UpdatedAt Timestamp `json:"updated_at"`
+	}{
+		taskCollaborator: taskCollaborator(c),
+		CreatedAt:        NewTimestamp(c.CreatedAt),
+		UpdatedAt:        NewTimestamp(c.UpdatedAt),
+	})
+}
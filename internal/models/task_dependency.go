@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TaskDependency records that a task is blocked by another task of the same
+// user: TaskID can't be completed until DependsOnID is
+type TaskDependency struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	TaskID      uint      `gorm:"not null;uniqueIndex:idx_task_dependency" json:"task_id"`
+	DependsOnID uint      `gorm:"not null;uniqueIndex:idx_task_dependency;index" json:"depends_on_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the TaskDependency model
+func (TaskDependency) TableName() string {
+	return "task_dependencies"
+}
+
+// MarshalJSON renders the dependency's timestamp in the configured time zone
+// and format
+func (d TaskDependency) MarshalJSON() ([]byte, error) {
+	type taskDependency TaskDependency
+	return json.Marshal(struct {
+		taskDependency
+		CreatedAt Timestamp `json:"created_at"`
+	}{
+		taskDependency: taskDependency(d),
+		CreatedAt:      NewTimestamp(d.CreatedAt),
+	})
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"task-manager/pkg/database"
+)
+
+// newTestDB returns a fresh, migrated in-memory database with one user
+func newTestDB(t *testing.T) (*gorm.DB, *User) {
+	t.Helper()
+	db, err := database.InitTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Reset() })
+	if err := SetupModels(db); err != nil {
+		t.Fatal(err)
+	}
+
+	user := User{Username: "alice", Email: "alice@example.com", Password: "password123", Role: RoleUser}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db, &user
+}
+
+func TestTaskBeforeSaveRejectsInvalidValues(t *testing.T) {
+	db, user := newTestDB(t)
+
+	t.Run("create", func(t *testing.T) {
+		for _, task := range []Task{
+			{UserID: user.ID, Title: "Task", Priority: "urgent", Status: StatusTodo},
+			{UserID: user.ID, Title: "Task", Priority: PriorityLow, Status: "done"},
+		} {
+			var invalid *InvalidValueError
+			if err := db.Create(&task).Error; !errors.As(err, &invalid) {
+				t.Errorf("creating a task with priority %q and status %q: got %v, want an InvalidValueError", task.Priority, task.Status, err)
+			}
+		}
+	})
+
+	t.Run("save", func(t *testing.T) {
+		task := Task{UserID: user.ID, Title: "Task", Priority: PriorityLow, Status: StatusTodo}
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		task.Status = "done"
+		var invalid *InvalidValueError
+		if err := db.Save(&task).Error; !errors.As(err, &invalid) || invalid.Field != "status" {
+			t.Fatalf("saving status done: got %v, want an InvalidValueError for status", err)
+		}
+
+		var stored Task
+		if err := db.First(&stored, task.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status != StatusTodo {
+			t.Errorf("stored status is %q, want it unchanged", stored.Status)
+		}
+	})
+
+	// GORM runs BeforeSave on the model passed to Model, whose fields still
+	// hold their old values, so updates of single columns get past the hook;
+	// the Value methods refuse them instead. GORM binds such values as given,
+	// so they have to be passed as a Priority or Status.
+	t.Run("single column updates", func(t *testing.T) {
+		task := Task{UserID: user.ID, Title: "Task", Priority: PriorityLow, Status: StatusTodo}
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		var invalid *InvalidValueError
+		if err := db.Model(&task).Update("status", Status("done")).Error; !errors.As(err, &invalid) {
+			t.Errorf("Update: got %v, want an InvalidValueError", err)
+		}
+		if err := db.Model(&task).UpdateColumn("priority", Priority("urgent")).Error; !errors.As(err, &invalid) {
+			t.Errorf("UpdateColumn: got %v, want an InvalidValueError", err)
+		}
+
+		var stored Task
+		if err := db.First(&stored, task.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.Priority != PriorityLow || stored.Status != StatusTodo {
+			t.Errorf("stored priority %q and status %q, want them unchanged", stored.Priority, stored.Status)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"task-manager/config"
+)
+
+// Timestamp is a time as rendered in API responses: in the zone configured
+// by APP_TIMEZONE, so that it doesn't depend on the server or database zone,
+// and in the layout configured by TIME_FORMAT
+type Timestamp time.Time
+
+// NewTimestamp returns t as a Timestamp
+func NewTimestamp(t time.Time) Timestamp {
+	return Timestamp(t)
+}
+
+// NewTimestampPtr is NewTimestamp for optional times
+func NewTimestampPtr(t *time.Time) *Timestamp {
+	if t == nil {
+		return nil
+	}
+	ts := Timestamp(*t)
+	return &ts
+}
+
+// MarshalJSON implements json.Marshaler. Zero times are left in UTC.
+func (ts Timestamp) MarshalJSON() ([]byte, error) {
+	t := time.Time(ts)
+	if !t.IsZero() {
+		t = t.In(config.Location())
+	}
+	return json.Marshal(t.Format(config.GetConfig().App.TimeFormat))
+}
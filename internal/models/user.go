@@ -1,11 +1,24 @@
 package models
 
 import (
+	"encoding/json"
 	"errors"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+
+	"task-manager/config"
+)
+
+// Role determines what a user is allowed to do
+type Role string
+
+const (
+	// RoleUser can manage their own tasks
+	RoleUser Role = "user"
+	// RoleAdmin can additionally manage other users
+	RoleAdmin Role = "admin"
 )
 
 // User represents the user model in the database
@@ -14,6 +27,7 @@ type User struct {
 	Username  string         `gorm:"size:100;not null;unique" json:"username"`
 	Email     string         `gorm:"size:100;not null;unique" json:"email"`
 	Password  string         `gorm:"size:255;not null" json:"-"`
+	Role      Role           `gorm:"size:20;not null;default:'user'" json:"role"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -24,16 +38,31 @@ func (User) TableName() string {
 	return "users"
 }
 
+// MarshalJSON renders the user's timestamps in the configured time zone and
+// format
+func (u User) MarshalJSON() ([]byte, error) {
+	type user User
+	return json.Marshal(struct {
+		user
+		CreatedAt Timestamp `json:"created_at"`
+		UpdatedAt Timestamp `json:"updated_at"`
+	}{
+		user:      user(u),
+		CreatedAt: NewTimestamp(u.CreatedAt),
+		UpdatedAt: NewTimestamp(u.UpdatedAt),
+	})
+}
+
 // BeforeSave is a GORM hook that encrypts the password before saving
 func (u *User) BeforeSave(tx *gorm.DB) error {
 	// Only hash the password if it has been modified
 	if u.Password != "" {
 		// Generate a hash from the password
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+		hashedPassword, err := HashPassword(u.Password)
 		if err != nil {
 			return err
 		}
-		u.Password = string(hashedPassword)
+		u.Password = hashedPassword
 	}
 	return nil
 }
@@ -48,4 +77,23 @@ func (u *User) CheckPassword(password string) error {
 		return err
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// NeedsRehash reports whether the stored password hash uses a lower bcrypt
+// cost than currently configured
+func (u *User) NeedsRehash() bool {
+	cost, err := bcrypt.Cost([]byte(u.Password))
+	if err != nil {
+		return false
+	}
+	return cost < config.GetConfig().Security.BcryptCost
+}
+
+// HashPassword hashes a password with the configured bcrypt cost
+func HashPassword(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), config.GetConfig().Security.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
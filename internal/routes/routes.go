@@ -2,35 +2,38 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"task-manager/config"
+	_ "task-manager/docs" // Generated Swagger spec
 	"task-manager/internal/handlers"
 	"task-manager/internal/middlewares"
+	"task-manager/internal/models"
 )
 
 // SetupRoutes configures all the API routes for the application
 func SetupRoutes(router *gin.Engine) {
-	// Setup API routes
-	api := router.Group("/api")
-	{
-		// Public routes (no authentication required)
-		auth := api.Group("/auth")
-		{
-			auth.POST("/register", handlers.Register)
-			auth.POST("/login", handlers.Login)
-		}
-
-		// Protected routes (authentication required)
-		tasks := api.Group("/tasks")
-		tasks.Use(middlewares.AuthMiddleware())
-		{
-			tasks.POST("/", handlers.CreateTask)
-			tasks.GET("/", handlers.GetTasks)
-			tasks.GET("/:id", handlers.GetTask)
-			tasks.PUT("/:id", handlers.UpdateTask)
-			tasks.PATCH("/:id/status", handlers.UpdateTaskStatus)
-			tasks.DELETE("/:id", handlers.DeleteTask)
-		}
-	}
+	// Security headers apply to every response
+	router.Use(middlewares.SecurityHeadersMiddleware())
+
+	// Bound request bodies so a huge upload can't exhaust memory
+	router.Use(middlewares.BodySizeLimitMiddleware(int64(config.GetConfig().App.MaxRequestBodySize)))
+
+	// Answer unknown paths and methods with the usual error body. Gin sets
+	// the Allow header of 405 responses itself.
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(handlers.NoRoute)
+	router.NoMethod(handlers.NoMethod)
+
+	// Versioned API routes. The unversioned /api prefix is kept as an alias
+	// of v1 for backward compatibility; future versions get their own group
+	// (e.g. /api/v2) alongside it. Requests through the alias are marked so
+	// that task updates without a version keep working there.
+	// Both prefixes share the rate limiter so that a user has one count
+	userRateLimit := middlewares.UserRateLimitMiddleware()
+	setupV1Routes(router.Group("/api/v1"), userRateLimit)
+	setupV1Routes(router.Group("/api", middlewares.LegacyAPIMiddleware()), userRateLimit)
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -38,4 +41,92 @@ func SetupRoutes(router *gin.Engine) {
 			"status": "ok",
 		})
 	})
-}
\ No newline at end of file
+
+	// Readiness check endpoint (verifies database connectivity)
+	router.GET("/ready", handlers.Ready)
+
+	// Build information endpoint
+	router.GET("/version", handlers.Version)
+
+	// Swagger UI and OpenAPI spec (disabled in production unless enabled explicitly)
+	if config.IsSwaggerEnabled() {
+		router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	}
+}
+
+// setupV1Routes registers the version 1 API routes on the given group.
+// userRateLimit runs after authentication on every authenticated route.
+func setupV1Routes(api *gin.RouterGroup, userRateLimit gin.HandlerFunc) {
+	// Maintenance mode refuses writes. Logging in and switching the mode
+	// itself stay available so that admins can turn it off again.
+	maintenance := middlewares.MaintenanceMiddleware()
+
+	// Public routes (no authentication required)
+	auth := api.Group("/auth")
+	{
+		auth.POST("/register", maintenance, handlers.Register)
+		auth.POST("/login", handlers.Login)
+		auth.GET("/introspect", handlers.IntrospectToken)
+	}
+
+	// Protected routes (authentication required)
+	tasks := api.Group("/tasks")
+	tasks.Use(maintenance, middlewares.AuthMiddleware(), userRateLimit)
+	{
+		tasks.POST("/", handlers.CreateTask)
+		tasks.POST("/validate", handlers.ValidateTask)
+		tasks.GET("/", handlers.GetTasks)
+		tasks.DELETE("/", handlers.DeleteTasks)
+		tasks.GET("/count", handlers.CountTasks)
+		tasks.GET("/upcoming", handlers.GetUpcomingTasks)
+		tasks.GET("/board", handlers.GetBoard)
+		tasks.GET("/completed", handlers.GetCompletedReport)
+		tasks.GET("/batch", handlers.GetTasksBatch)
+		tasks.PATCH("/reorder", handlers.ReorderTasks)
+		tasks.GET("/:id", handlers.GetTask)
+		tasks.PUT("/:id", handlers.UpdateTask)
+		tasks.PATCH("/:id", handlers.PatchTask)
+		tasks.PATCH("/:id/status", handlers.UpdateTaskStatus)
+		tasks.PATCH("/:id/pin", handlers.PinTask)
+		tasks.POST("/:id/snooze", handlers.SnoozeTask)
+		tasks.POST("/:id/archive", handlers.ArchiveTask)
+		tasks.POST("/:id/unarchive", handlers.UnarchiveTask)
+		tasks.POST("/:id/assign", handlers.AssignTask)
+		tasks.GET("/:id/activity", handlers.GetTaskActivity)
+		tasks.POST("/:id/dependencies", handlers.AddTaskDependency)
+		tasks.GET("/:id/dependencies", handlers.GetTaskDependencies)
+		tasks.POST("/:id/collaborators", handlers.ShareTask)
+		tasks.GET("/:id/collaborators", handlers.GetTaskCollaborators)
+		tasks.DELETE("/:id/collaborators/:user_id", handlers.UnshareTask)
+		tasks.DELETE("/:id", handlers.DeleteTask)
+	}
+
+	// Task event stream. EventSource can't send headers, so the token may
+	// also be given in the access_token query parameter.
+	api.GET("/tasks/events", middlewares.QueryTokenMiddleware(), middlewares.AuthMiddleware(), userRateLimit, handlers.StreamTaskEvents)
+
+	// Current user's resources (authentication required)
+	me := api.Group("/me")
+	me.Use(maintenance, middlewares.AuthMiddleware(), userRateLimit)
+	{
+		me.POST("/api-keys", handlers.CreateAPIKey)
+		me.GET("/api-keys", handlers.ListAPIKeys)
+		me.DELETE("/api-keys/:id", handlers.DeleteAPIKey)
+		me.GET("/streak", handlers.GetStreak)
+		me.GET("/sessions", handlers.ListSessions)
+		me.DELETE("/sessions/:id", handlers.DeleteSession)
+		me.GET("/export", handlers.ExportData)
+	}
+
+	// Administration (admin role required)
+	admin := api.Group("/admin")
+	admin.Use(middlewares.AuthMiddleware(), userRateLimit, middlewares.RequireRole(models.RoleAdmin))
+	{
+		admin.GET("/users", handlers.ListUsers)
+		admin.POST("/users", maintenance, handlers.CreateUser)
+		admin.POST("/users/:id/transfer-tasks", maintenance, handlers.TransferTasks)
+		admin.POST("/users/:id/unlock", handlers.UnlockUser)
+		admin.GET("/maintenance", handlers.GetMaintenanceMode)
+		admin.PUT("/maintenance", handlers.SetMaintenanceMode)
+	}
+}
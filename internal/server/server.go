@@ -0,0 +1,243 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"task-manager/config"
+	"task-manager/internal/middlewares"
+	"task-manager/internal/models"
+	"task-manager/internal/routes"
+	"task-manager/internal/services"
+	"task-manager/pkg/database"
+	"task-manager/pkg/version"
+)
+
+// shutdownTimeout is how long in-flight requests get to finish once the
+// server is asked to stop
+const shutdownTimeout = 10 * time.Second
+
+// Server is the API server together with its background jobs
+type Server struct {
+	cfg        *config.Config
+	router     *gin.Engine
+	httpServer *http.Server
+}
+
+// New connects to the database, applies pending migrations and sets up the
+// router. cfg should already have been validated.
+func New(cfg *config.Config) (*Server, error) {
+	// Set Gin mode based on environment
+	if cfg.App.Env == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	} else {
+		gin.SetMode(gin.DebugMode)
+	}
+
+	// Initialize database connection
+	db, err := database.InitDB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	// Setup database models and migrations
+	if err := models.SetupModels(db); err != nil {
+		return nil, fmt.Errorf("failed to setup database models: %w", err)
+	}
+
+	// Initialize Gin router
+	router := gin.New()
+
+	// Only take the client IP from X-Forwarded-For when the request comes
+	// from a known proxy
+	if err := router.SetTrustedProxies(cfg.App.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	if len(cfg.App.TrustedProxies) == 0 && cfg.App.Env == "production" {
+		log.Printf("WARNING: TRUSTED_PROXIES is not set; behind a load balancer all requests will appear to come from its IP")
+	}
+
+	// Start in maintenance mode if configured; admins can switch it later
+	middlewares.SetMaintenanceMode(cfg.App.MaintenanceMode)
+	if cfg.App.MaintenanceMode {
+		log.Printf("Maintenance mode is on; write requests will be refused")
+	}
+
+	// Apply middlewares. Recovery comes after the logger so that requests
+	// that panic are still logged.
+	router.Use(middlewares.LoggerMiddleware())
+	router.Use(middlewares.RecoveryMiddleware())
+	router.Use(middlewares.GzipMiddleware())
+
+	// Setup routes using the routes package
+	routes.SetupRoutes(router)
+
+	httpServer := &http.Server{
+		Addr:    ":" + cfg.App.Port,
+		Handler: router,
+	}
+	// Event streams never finish on their own, so end them when shutting down
+	httpServer.RegisterOnShutdown(services.TaskEvents().CloseAll)
+
+	return &Server{
+		cfg:        cfg,
+		router:     router,
+		httpServer: httpServer,
+	}, nil
+}
+
+// Handler returns the server's HTTP handler
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// Run starts the background jobs and serves HTTP until ctx is cancelled,
+// then shuts down gracefully, letting in-flight requests finish
+func (s *Server) Run(ctx context.Context) error {
+	// Start background jobs
+	scheduleReminders(ctx, s.cfg.Reminders.PollInterval)
+	scheduleIdempotencyKeyCleanup(ctx, s.cfg.Idempotency.CleanupInterval, services.NewIdempotencyService().PurgeExpired)
+	scheduleSessionCleanup(ctx, s.cfg.Sessions.CleanupInterval, services.NewSessionService().PurgeExpired)
+	if s.cfg.Tasks.TrashRetentionDays > 0 {
+		retention := time.Duration(s.cfg.Tasks.TrashRetentionDays) * 24 * time.Hour
+		scheduleTrashPurge(ctx, s.cfg.Tasks.TrashPurgeInterval, retention, services.NewTaskService().PurgeDeletedTasks)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		logStarting(s.cfg, s.httpServer.Addr)
+		errs <- s.httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	log.Printf("Server stopped")
+	return nil
+}
+
+// logStarting logs a single JSON line with the build and the effective
+// configuration, secrets redacted
+func logStarting(cfg *config.Config, addr string) {
+	entry, err := json.Marshal(struct {
+		Message string          `json:"message"`
+		Version string          `json:"version"`
+		Commit  string          `json:"commit"`
+		Addr    string          `json:"addr"`
+		Config  json.RawMessage `json:"config"`
+	}{
+		Message: "server starting",
+		Version: version.Version,
+		Commit:  version.Commit,
+		Addr:    addr,
+		Config:  json.RawMessage(cfg.SafeString()),
+	})
+	if err != nil {
+		log.Printf("Server %s (commit %s) starting on %s", version.Version, version.Commit, addr)
+		return
+	}
+	log.Print(string(entry))
+}
+
+// scheduleReminders polls for due task reminders at the given interval
+// until ctx is cancelled
+func scheduleReminders(ctx context.Context, interval time.Duration) {
+	reminders := services.NewReminderService(services.LogNotifier{})
+
+	every(ctx, interval, func(now time.Time) {
+		sent, err := reminders.DispatchDue(now)
+		if err != nil {
+			log.Printf("Failed to dispatch task reminders: %v", err)
+		}
+		if sent > 0 {
+			log.Printf("Dispatched %d task reminders", sent)
+		}
+	})
+
+	log.Printf("Task reminders scheduled every %s", interval)
+}
+
+// scheduleIdempotencyKeyCleanup calls purge at the given interval to delete
+// expired idempotency keys until ctx is cancelled, logging how many each run
+// removed
+func scheduleIdempotencyKeyCleanup(ctx context.Context, interval time.Duration, purge func(now time.Time) (int64, error)) {
+	every(ctx, interval, func(now time.Time) {
+		purged, err := purge(now)
+		if err != nil {
+			log.Printf("Failed to purge idempotency keys: %v", err)
+			return
+		}
+		log.Printf("Purged %d expired idempotency keys", purged)
+	})
+
+	log.Printf("Idempotency key cleanup scheduled every %s", interval)
+}
+
+// scheduleSessionCleanup calls purge at the given interval to delete expired
+// login sessions until ctx is cancelled, logging how many each run removed
+func scheduleSessionCleanup(ctx context.Context, interval time.Duration, purge func(ctx context.Context, now time.Time) (int64, error)) {
+	every(ctx, interval, func(now time.Time) {
+		purged, err := purge(ctx, now)
+		if err != nil {
+			log.Printf("Failed to purge sessions: %v", err)
+			return
+		}
+		log.Printf("Purged %d expired sessions", purged)
+	})
+
+	log.Printf("Session cleanup scheduled every %s", interval)
+}
+
+// scheduleTrashPurge calls purge at the given interval to remove tasks
+// soft-deleted more than retention ago until ctx is cancelled, logging how
+// many each run removed. Cancelling ctx also aborts a purge in progress.
+func scheduleTrashPurge(ctx context.Context, interval, retention time.Duration, purge func(ctx context.Context, cutoff time.Time) (int64, error)) {
+	every(ctx, interval, func(now time.Time) {
+		purged, err := purge(ctx, now.Add(-retention))
+		if err != nil {
+			log.Printf("Failed to purge deleted tasks: %v", err)
+			return
+		}
+		log.Printf("Purged %d deleted tasks past their retention period", purged)
+	})
+
+	log.Printf("Deleted task purge scheduled every %s", interval)
+}
+
+// every runs job in the background at the given interval until ctx is
+// cancelled
+func every(ctx context.Context, interval time.Duration, job func(now time.Time)) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				job(now)
+			}
+		}
+	}()
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduleSessionCleanup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs atomic.Int32
+	ran := make(chan struct{}, 1)
+	scheduleSessionCleanup(ctx, time.Millisecond, func(ctx context.Context, now time.Time) (int64, error) {
+		runs.Add(1)
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+		return 2, nil
+	})
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("session cleanup didn't run")
+	}
+
+	// No run starts once ctx is cancelled; one may still be finishing
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	stopped := runs.Load()
+	time.Sleep(20 * time.Millisecond)
+	if runs.Load() != stopped {
+		t.Error("session cleanup kept running after ctx was cancelled")
+	}
+}
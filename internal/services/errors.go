@@ -0,0 +1,17 @@
+package services
+
+import "task-manager/internal/apperrors"
+
+// Errors the services return that callers tell apart with errors.Is. They
+// are the apperrors sentinels, so handlers can respond with them as they are.
+var (
+	// ErrUsernameTaken is returned when registering or creating a user with
+	// a username that is already in use
+	ErrUsernameTaken = apperrors.ErrUsernameTaken
+	// ErrEmailTaken is returned when registering or creating a user with an
+	// email address that is already in use
+	ErrEmailTaken = apperrors.ErrEmailTaken
+	// ErrTaskNotFound is returned when a task doesn't exist or the user may
+	// not see it
+	ErrTaskNotFound = apperrors.ErrTaskNotFound
+)
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+
+	"task-manager/internal/models"
+	"task-manager/pkg/database"
+)
+
+// newTestDB installs a fresh, migrated in-memory database as the global one
+// for the duration of the test
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.InitTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := models.SetupModels(db); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := database.Reset(); err != nil {
+			t.Errorf("failed to close database: %v", err)
+		}
+	})
+	return db
+}
+
+// seedUser creates a user with the given username
+func seedUser(t *testing.T, db *gorm.DB, username string) *models.User {
+	t.Helper()
+	user := models.User{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: "password123",
+		Role:     models.RoleUser,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &user
+}
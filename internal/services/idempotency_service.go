@@ -0,0 +1,104 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+
+	"task-manager/internal/apperrors"
+	"task-manager/internal/models"
+	"task-manager/pkg/database"
+)
+
+// IdempotencyKeyTTL is how long an Idempotency-Key is remembered
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyService records the results of requests made with an
+// Idempotency-Key so that retries can be answered without repeating them
+type IdempotencyService struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyService creates a new instance of IdempotencyService
+func NewIdempotencyService() *IdempotencyService {
+	return &IdempotencyService{
+		db: database.GetDB(),
+	}
+}
+
+// WithTx returns a copy of the service that runs its queries on tx
+func (s *IdempotencyService) WithTx(tx *gorm.DB) *IdempotencyService {
+	return &IdempotencyService{
+		db: tx,
+	}
+}
+
+// Find returns the unexpired record for the user's key, or nil if the key
+// hasn't been used. requestHash must match the hash recorded with the key;
+// reusing a key for a different request returns ErrIdempotencyKeyUsed.
+func (s *IdempotencyService) Find(userID uint, key, requestHash string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	err := s.db.Clauses(dbresolver.Write).
+		Where(&models.IdempotencyKey{UserID: userID, Key: key}).
+		Where("created_at > ?", time.Now().Add(-IdempotencyKeyTTL)).
+		First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to look up idempotency key: %w", err)
+	}
+
+	if record.RequestHash != requestHash {
+		return nil, apperrors.ErrIdempotencyKeyUsed
+	}
+	return &record, nil
+}
+
+// Save records that the user's key produced the given task. An expired
+// record for the same key is replaced.
+func (s *IdempotencyService) Save(userID uint, key, requestHash string, taskID uint) error {
+	if err := s.db.Where(&models.IdempotencyKey{UserID: userID, Key: key}).
+		Where("created_at <= ?", time.Now().Add(-IdempotencyKeyTTL)).
+		Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return fmt.Errorf("failed to replace expired idempotency key: %w", err)
+	}
+
+	record := models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		TaskID:      taskID,
+	}
+	if err := s.db.Create(&record).Error; err != nil {
+		return fmt.Errorf("failed to save idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpired deletes keys older than IdempotencyKeyTTL and returns how
+// many were removed
+func (s *IdempotencyService) PurgeExpired(now time.Time) (int64, error) {
+	result := s.db.Where("created_at <= ?", now.Add(-IdempotencyKeyTTL)).Delete(&models.IdempotencyKey{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// hashRequest returns a fingerprint of req used to detect a key being reused
+// with a different request body
+func hashRequest(req interface{}) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"task-manager/config"
+	"task-manager/internal/apperrors"
+	"task-manager/internal/models"
+	"task-manager/pkg/database"
+)
+
+// LoginAttemptService throttles logins per account by locking an email
+// address after too many consecutive failed attempts, regardless of which
+// IP addresses they came from
+type LoginAttemptService struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptService creates a new instance of LoginAttemptService
+func NewLoginAttemptService() *LoginAttemptService {
+	return &LoginAttemptService{
+		db: database.GetDB(),
+	}
+}
+
+// WithTx returns a copy of the service that runs its queries on tx
+func (s *LoginAttemptService) WithTx(tx *gorm.DB) *LoginAttemptService {
+	return &LoginAttemptService{
+		db: tx,
+	}
+}
+
+// CheckLocked returns ErrAccountLocked if the email is currently locked out
+func (s *LoginAttemptService) CheckLocked(email string, now time.Time) error {
+	attempt, err := s.find(email)
+	if err != nil || attempt == nil {
+		return err
+	}
+
+	if attempt.IsLocked(now) {
+		return apperrors.ErrAccountLocked.WithMessage(fmt.Sprintf(
+			"Too many failed login attempts; try again after %s",
+			attempt.LockedUntil.In(config.Location()).Format(time.RFC3339),
+		))
+	}
+	return nil
+}
+
+// IsLocked reports whether the email is currently locked out
+func (s *LoginAttemptService) IsLocked(email string, now time.Time) (bool, error) {
+	attempt, err := s.find(email)
+	if err != nil || attempt == nil {
+		return false, err
+	}
+	return attempt.IsLocked(now), nil
+}
+
+// RecordFailure counts a failed login for the email, locking it once the
+// configured number of failures falls within the attempt window
+func (s *LoginAttemptService) RecordFailure(email string, now time.Time) error {
+	cfg := config.GetConfig().Security
+
+	attempt, err := s.find(email)
+	if err != nil {
+		return err
+	}
+	if attempt == nil {
+		attempt = &models.LoginAttempt{Email: normalizeEmail(email), WindowStart: now}
+	}
+
+	// Start counting afresh once the window has passed
+	if now.Sub(attempt.WindowStart) > cfg.LoginAttemptWindow {
+		attempt.Failures = 0
+		attempt.WindowStart = now
+	}
+
+	attempt.Failures++
+	if attempt.Failures >= cfg.LoginMaxAttempts {
+		lockedUntil := now.Add(cfg.LoginLockoutDuration)
+		attempt.LockedUntil = &lockedUntil
+		attempt.Failures = 0
+		attempt.WindowStart = lockedUntil
+	}
+
+	if err := s.db.Save(attempt).Error; err != nil {
+		// A concurrent failure created the row first; count against it
+		if attempt.ID == 0 && isDuplicateKeyError(err) {
+			return s.RecordFailure(email, now)
+		}
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	return nil
+}
+
+// Reset clears the failure count and any lockout for the email, e.g. after a
+// successful login
+func (s *LoginAttemptService) Reset(email string) error {
+	if err := s.db.Where("email = ?", normalizeEmail(email)).Delete(&models.LoginAttempt{}).Error; err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+	return nil
+}
+
+// find returns the tracked attempts for the email, or nil if there are none
+func (s *LoginAttemptService) find(email string) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	if err := s.db.Where("email = ?", normalizeEmail(email)).First(&attempt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to look up login attempts: %w", err)
+	}
+	return &attempt, nil
+}
+
+// normalizeEmail makes lookups case-insensitive
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+
+	"task-manager/internal/models"
+	"task-manager/pkg/database"
+)
+
+// reminderBatchSize limits how many reminders are dispatched per poll
+const reminderBatchSize = 100
+
+// Notifier delivers a reminder for a task
+type Notifier interface {
+	Notify(task models.Task) error
+}
+
+// LogNotifier delivers reminders as structured log lines
+type LogNotifier struct{}
+
+// reminderEvent represents the structured log entry written for a reminder
+type reminderEvent struct {
+	Event    string     `json:"event"`
+	TaskID   uint       `json:"task_id"`
+	UserID   uint       `json:"user_id"`
+	Title    string     `json:"title"`
+	DueDate  *time.Time `json:"due_date,omitempty"`
+	RemindAt *time.Time `json:"remind_at"`
+}
+
+// Notify logs the reminder as a JSON event
+func (LogNotifier) Notify(task models.Task) error {
+	event, err := json.Marshal(reminderEvent{
+		Event:    "task_reminder",
+		TaskID:   task.ID,
+		UserID:   task.UserID,
+		Title:    task.Title,
+		DueDate:  task.DueDate,
+		RemindAt: task.RemindAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode reminder: %w", err)
+	}
+	log.Printf("[REMINDER] %s", event)
+	return nil
+}
+
+// ReminderService dispatches notifications for tasks whose reminder is due
+type ReminderService struct {
+	db       *gorm.DB
+	notifier Notifier
+}
+
+// NewReminderService creates a new instance of ReminderService
+func NewReminderService(notifier Notifier) *ReminderService {
+	return &ReminderService{
+		db:       database.GetDB(),
+		notifier: notifier,
+	}
+}
+
+// DispatchDue notifies about every unfinished task whose RemindAt has passed
+// and that hasn't been reminded yet. Each task is claimed by setting
+// reminded_at before notifying, so a reminder is sent at most once even when
+// several instances poll concurrently. Returns the number of reminders sent.
+func (s *ReminderService) DispatchDue(now time.Time) (int, error) {
+	var tasks []models.Task
+	if err := s.db.Clauses(dbresolver.Write).
+		Where("remind_at <= ? AND reminded_at IS NULL AND status <> ?", now, models.StatusCompleted).
+		Order("remind_at asc").
+		Limit(reminderBatchSize).
+		Find(&tasks).Error; err != nil {
+		return 0, fmt.Errorf("failed to find due reminders: %w", err)
+	}
+
+	sent := 0
+	for _, task := range tasks {
+		// Claim the reminder; skip it if another instance got there first
+		result := s.db.Model(&models.Task{}).
+			Where("id = ? AND reminded_at IS NULL", task.ID).
+			UpdateColumn("reminded_at", now)
+		if result.Error != nil {
+			return sent, fmt.Errorf("failed to mark task %d as reminded: %w", task.ID, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		if err := s.notifier.Notify(task); err != nil {
+			log.Printf("Failed to send reminder for task %d: %v", task.ID, err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"task-manager/internal/apperrors"
+	"task-manager/internal/models"
+	"task-manager/pkg/database"
+	"task-manager/pkg/utils"
+)
+
+// sessionTouchInterval is how often a session's LastUsedAt is updated while
+// it is in use, so that not every authenticated request writes to the
+// database
+const sessionTouchInterval = time.Minute
+
+// ClientInfo describes the client a user logs in from
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// SessionService provides methods for login session operations
+type SessionService struct {
+	db *gorm.DB
+}
+
+// NewSessionService creates a new instance of SessionService
+func NewSessionService() *SessionService {
+	return &SessionService{
+		db: database.GetDB(),
+	}
+}
+
+// WithTx returns a copy of the service that runs its queries on tx
+func (s *SessionService) WithTx(tx *gorm.DB) *SessionService {
+	return &SessionService{
+		db: tx,
+	}
+}
+
+// StartSession records a new session for the user from client lasting d,
+// and returns a token for it together with the token's expiry time. The
+// user's expired sessions are removed on the way.
+func (s *SessionService) StartSession(ctx context.Context, userID uint, client ClientInfo, d time.Duration) (string, time.Time, error) {
+	db := s.db.WithContext(ctx)
+	now := time.Now()
+
+	if err := db.Where("user_id = ? AND expires_at <= ?", userID, now).Delete(&models.Session{}).Error; err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to remove expired sessions: %w", err)
+	}
+
+	session := models.Session{
+		UserID:    userID,
+		UserAgent: truncate(client.UserAgent, 255),
+		IP:        truncate(client.IP, 45),
+		ExpiresAt: now.Add(d),
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	token, expiresAt, err := utils.GenerateSessionToken(userID, session.ID, session.ExpiresAt)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate JWT token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// Authenticate returns ErrSessionRevoked unless the user's session still
+// exists, and records the session's use in the background
+func (s *SessionService) Authenticate(ctx context.Context, sessionID uint, userID uint) error {
+	var session models.Session
+	if err := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrSessionRevoked
+		}
+		return fmt.Errorf("failed to look up session: %w", err)
+	}
+
+	now := time.Now()
+	if session.LastUsedAt == nil || now.Sub(*session.LastUsedAt) >= sessionTouchInterval {
+		go s.touch(session.ID, now)
+	}
+	return nil
+}
+
+// ListSessions returns the user's unexpired sessions, most recent first,
+// marking the one with currentID as the current session
+func (s *SessionService) ListSessions(ctx context.Context, userID uint, currentID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("created_at desc, id desc").
+		Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession deletes one of the user's sessions, so that its token is
+// no longer accepted
+func (s *SessionService) RevokeSession(ctx context.Context, sessionID uint, userID uint) error {
+	result := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", sessionID, userID).Delete(&models.Session{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrSessionNotFound
+	}
+	return nil
+}
+
+// PurgeExpired deletes the sessions of all users that expired by now and
+// returns how many were removed
+func (s *SessionService) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.Session{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge sessions: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// touch updates the session's LastUsedAt without blocking the request
+func (s *SessionService) touch(sessionID uint, usedAt time.Time) {
+	if err := s.db.Model(&models.Session{}).Where("id = ?", sessionID).UpdateColumn("last_used_at", usedAt).Error; err != nil {
+		log.Printf("Failed to update last use of session %d: %v", sessionID, err)
+	}
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 character
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task-manager/internal/models"
+)
+
+func TestSessionPurgeExpired(t *testing.T) {
+	db := newTestDB(t)
+	alice := seedUser(t, db, "alice")
+	bob := seedUser(t, db, "bob")
+
+	now := time.Now()
+	sessions := []models.Session{
+		{UserID: alice.ID, ExpiresAt: now.Add(-time.Hour)},
+		{UserID: bob.ID, ExpiresAt: now.Add(-time.Minute)},
+		{UserID: bob.ID, ExpiresAt: now.Add(time.Hour)},
+	}
+	if err := db.Create(&sessions).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := NewSessionService().PurgeExpired(context.Background(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 2 {
+		t.Errorf("purged %d sessions, want the 2 expired ones", purged)
+	}
+
+	var remaining []models.Session
+	if err := db.Find(&remaining).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != sessions[2].ID {
+		t.Errorf("got %d sessions left, want only the unexpired one", len(remaining))
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"task-manager/internal/models"
+)
+
+// boardStatuses are the columns of the task board, in display order
+var boardStatuses = []models.Status{models.StatusTodo, models.StatusInProgress, models.StatusCompleted}
+
+// BoardColumn holds the first tasks of one status on a user's task board
+type BoardColumn struct {
+	Status models.Status
+	// Tasks are in the user's manual order
+	Tasks []models.Task
+	// Total is how many of the user's unarchived tasks have the status
+	Total int64
+}
+
+// GetBoard returns the user's tasks grouped into one column per status, each
+// holding at most limitPerColumn tasks in manual order. Archived tasks are
+// left out. limitPerColumn defaults to and is capped like a page size.
+func (s *TaskService) GetBoard(ctx context.Context, userID uint, limitPerColumn int) ([]BoardColumn, error) {
+	_, limit := normalizePagination(1, limitPerColumn)
+	db := s.db.WithContext(ctx)
+
+	// Count every column in one query
+	var counts []struct {
+		Status models.Status
+		Total  int64
+	}
+	if err := db.Model(&models.Task{}).
+		Select("status, COUNT(*) AS total").
+		Where("user_id = ? AND archived_at IS NULL", userID).
+		Group("status").
+		Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
+	}
+	totals := make(map[models.Status]int64, len(counts))
+	for _, count := range counts {
+		totals[count.Status] = count.Total
+	}
+
+	columns := make([]BoardColumn, len(boardStatuses))
+	for i, status := range boardStatuses {
+		columns[i] = BoardColumn{Status: status, Tasks: []models.Task{}, Total: totals[status]}
+		if columns[i].Total == 0 {
+			continue
+		}
+		if err := db.Where("user_id = ? AND status = ? AND archived_at IS NULL", userID, status).
+			Order("position, id").
+			Limit(limit).
+			Find(&columns[i].Tasks).Error; err != nil {
+			return nil, fmt.Errorf("failed to retrieve tasks: %w", err)
+		}
+	}
+
+	return columns, nil
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"task-manager/internal/apperrors"
+	"task-manager/internal/models"
+)
+
+// AddDependency records that taskID is blocked by dependsOnID. Both tasks
+// must belong to the user, and links that would close a cycle are rejected.
+func (s *TaskService) AddDependency(ctx context.Context, taskID, dependsOnID, userID uint) (*models.TaskDependency, error) {
+	if taskID == dependsOnID {
+		return nil, apperrors.ErrDependencyCycle.WithMessage("A task cannot depend on itself")
+	}
+
+	dependency := models.TaskDependency{
+		TaskID:      taskID,
+		DependsOnID: dependsOnID,
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Task{}).
+			Where("id IN ? AND user_id = ?", []uint{taskID, dependsOnID}, userID).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to retrieve tasks: %w", err)
+		}
+		if count != 2 {
+			return ErrTaskNotFound
+		}
+
+		cycle, err := dependsOn(tx, dependsOnID, taskID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return apperrors.ErrDependencyCycle
+		}
+
+		if err := tx.Create(&dependency).Error; err != nil {
+			if isDuplicateKeyError(err) {
+				return apperrors.ErrDependencyExists
+			}
+			return fmt.Errorf("failed to add dependency: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dependency, nil
+}
+
+// GetDependencies returns the tasks blocking a task owned by the user.
+// Deleted tasks no longer block and are left out.
+func (s *TaskService) GetDependencies(ctx context.Context, taskID, userID uint) ([]models.Task, error) {
+	if _, err := s.GetTaskFields(ctx, taskID, userID, []string{"id"}, nil); err != nil {
+		return nil, err
+	}
+
+	tasks := []models.Task{}
+	if err := s.db.WithContext(ctx).
+		Where("id IN (?)", blockingTaskIDs(s.db, taskID)).
+		Order("id asc").
+		Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve dependencies: %w", err)
+	}
+	return tasks, nil
+}
+
+// checkDependenciesCompleted returns ErrTaskBlocked if any task blocking
+// taskID isn't completed yet
+func (s *TaskService) checkDependenciesCompleted(ctx context.Context, taskID uint) error {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Task{}).
+		Where("id IN (?) AND status <> ?", blockingTaskIDs(s.db, taskID), models.StatusCompleted).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check dependencies: %w", err)
+	}
+	if count > 0 {
+		return apperrors.ErrTaskBlocked
+	}
+	return nil
+}
+
+// blockingTaskIDs returns a subquery selecting the IDs of the tasks taskID
+// depends on directly
+func blockingTaskIDs(db *gorm.DB, taskID uint) *gorm.DB {
+	return db.Model(&models.TaskDependency{}).Select("depends_on_id").Where("task_id = ?", taskID)
+}
+
+// dependsOn reports whether from depends on target, directly or through
+// other tasks, by walking the dependency graph breadth first
+func dependsOn(tx *gorm.DB, from, target uint) (bool, error) {
+	visited := map[uint]bool{from: true}
+	frontier := []uint{from}
+	for len(frontier) > 0 {
+		var next []uint
+		if err := tx.Model(&models.TaskDependency{}).
+			Where("task_id IN ?", frontier).
+			Pluck("depends_on_id", &next).Error; err != nil {
+			return false, fmt.Errorf("failed to check dependencies: %w", err)
+		}
+
+		frontier = frontier[:0]
+		for _, id := range next {
+			if id == target {
+				return true, nil
+			}
+			if !visited[id] {
+				visited[id] = true
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"sync"
+
+	"task-manager/internal/models"
+)
+
+// TaskEventType describes what happened to a task
+type TaskEventType string
+
+const (
+	// Task event types
+	TaskCreated      TaskEventType = "created"
+	TaskUpdated      TaskEventType = "updated"
+	TaskDeleted      TaskEventType = "deleted"
+	TasksBulkDeleted TaskEventType = "bulk_deleted"
+	TasksReordered   TaskEventType = "reordered"
+)
+
+// TaskEvent is a change to one of a user's tasks, streamed to their
+// subscribers
+type TaskEvent struct {
+	Type   TaskEventType `json:"type"`
+	TaskID uint          `json:"task_id,omitempty"`
+	// Task is the task after the change; nil for deletions
+	Task *models.Task `json:"task,omitempty"`
+	// Count is the number of tasks removed by a bulk deletion or moved by
+	// a reordering
+	Count int64 `json:"count,omitempty"`
+}
+
+// taskEventBuffer is how many events a subscriber may fall behind before
+// further events to it are dropped
+const taskEventBuffer = 16
+
+// TaskEventHub fans task events out to the subscribers of each user. It is
+// in-memory, so subscribers only see changes made through the same
+// server instance.
+type TaskEventHub struct {
+	mu          sync.Mutex
+	subscribers map[uint]map[chan TaskEvent]struct{}
+}
+
+// NewTaskEventHub creates an empty TaskEventHub
+func NewTaskEventHub() *TaskEventHub {
+	return &TaskEventHub{
+		subscribers: make(map[uint]map[chan TaskEvent]struct{}),
+	}
+}
+
+var defaultTaskEventHub = NewTaskEventHub()
+
+// TaskEvents returns the hub that task handlers publish to
+func TaskEvents() *TaskEventHub {
+	return defaultTaskEventHub
+}
+
+// Subscribe returns a channel receiving the user's task events and a
+// function that must be called to unsubscribe once the caller is done. The
+// channel is closed if the hub is closed.
+func (h *TaskEventHub) Subscribe(userID uint) (<-chan TaskEvent, func()) {
+	ch := make(chan TaskEvent, taskEventBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan TaskEvent]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subscribers[userID], ch)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+		})
+	}
+}
+
+// CloseAll closes the channels of all subscribers, ending their streams
+func (h *TaskEventHub) CloseAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for userID, channels := range h.subscribers {
+		for ch := range channels {
+			close(ch)
+		}
+		delete(h.subscribers, userID)
+	}
+}
+
+// Publish sends an event to all of the user's subscribers. It never blocks:
+// subscribers whose buffer is full miss the event.
+func (h *TaskEventHub) Publish(userID uint, event TaskEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[userID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
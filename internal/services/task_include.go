@@ -0,0 +1,59 @@
+package services
+
+import (
+	"gorm.io/gorm"
+)
+
+// Relations that can be loaded along with tasks
+const (
+	IncludeUser     = "user"
+	IncludeAssignee = "assignee"
+)
+
+// taskRelations maps each relation to the association it preloads and the
+// task column the association is loaded by
+var taskRelations = map[string]struct{ association, foreignKey string }{
+	IncludeUser:     {"User", "user_id"},
+	IncludeAssignee: {"Assignee", "assignee_id"},
+}
+
+// preloadRelations loads the relations named in include with one query per
+// relation, however many tasks there are. Unknown names are ignored.
+func preloadRelations(include []string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, name := range include {
+			if relation, ok := taskRelations[name]; ok {
+				db = db.Preload(relation.association)
+			}
+		}
+		return db
+	}
+}
+
+// withForeignKeys adds the columns the relations in include are loaded by
+// to fields. Empty fields, meaning all columns, is returned unchanged.
+func withForeignKeys(fields, include []string) []string {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	columns := fields[:len(fields):len(fields)]
+	for _, name := range include {
+		relation, ok := taskRelations[name]
+		if !ok || containsString(columns, relation.foreignKey) {
+			continue
+		}
+		columns = append(columns, relation.foreignKey)
+	}
+	return columns
+}
+
+// containsString reports whether s is one of values
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
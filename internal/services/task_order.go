@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"task-manager/internal/apperrors"
+	"task-manager/internal/models"
+)
+
+// lastPosition returns the highest position among the user's tasks, or 0 if
+// they have none
+func lastPosition(db *gorm.DB, userID uint) (int, error) {
+	var last int
+	if err := db.Model(&models.Task{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(MAX(position), 0)").
+		Scan(&last).Error; err != nil {
+		return 0, fmt.Errorf("failed to find last task position: %w", err)
+	}
+	return last, nil
+}
+
+// ReorderTasks puts the user's tasks taskIDs into the given order and
+// returns them in that order. The tasks trade the positions they already
+// hold among themselves, so tasks not listed keep their place and a subset,
+// e.g. one Kanban column, can be reordered on its own.
+func (s *TaskService) ReorderTasks(ctx context.Context, userID uint, taskIDs []uint) ([]models.Task, error) {
+	seen := make(map[uint]bool, len(taskIDs))
+	for _, id := range taskIDs {
+		if seen[id] {
+			return nil, apperrors.ErrValidation.WithFields(map[string]string{
+				"task_ids": fmt.Sprintf("task %d is listed more than once", id),
+			})
+		}
+		seen[id] = true
+	}
+
+	var tasks []models.Task
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var positions []int
+		if err := tx.Model(&models.Task{}).
+			Where("user_id = ? AND id IN ?", userID, taskIDs).
+			Order("position").
+			Pluck("position", &positions).Error; err != nil {
+			return fmt.Errorf("failed to find tasks: %w", err)
+		}
+		if len(positions) != len(taskIDs) {
+			return ErrTaskNotFound.WithMessage("One or more tasks were not found")
+		}
+
+		for i, id := range taskIDs {
+			// Spread out tasks sharing a position so the new order is strict
+			if i > 0 && positions[i] <= positions[i-1] {
+				positions[i] = positions[i-1] + 1
+			}
+			if err := tx.Model(&models.Task{}).Where("id = ?", id).Update("position", positions[i]).Error; err != nil {
+				return fmt.Errorf("failed to reorder tasks: %w", err)
+			}
+		}
+
+		return tx.Where("id IN ?", taskIDs).Order("position").Find(&tasks).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
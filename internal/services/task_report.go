@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"task-manager/config"
+	"task-manager/internal/models"
+)
+
+// completedDayLayout is the format of CompletedDay.Date
+const completedDayLayout = "2006-01-02"
+
+// CompletedDay holds the tasks a user completed on one day
+type CompletedDay struct {
+	// Date is the day in the configured time zone, e.g. "2024-03-01"
+	Date string
+	// Tasks are in the order they were completed
+	Tasks []models.Task
+}
+
+// CompletedReport returns the user's tasks completed from from up to but not
+// including to, grouped by day in the configured time zone. Every day of the
+// range is included, with no tasks if none were completed on it.
+//
+// The tasks are grouped here rather than with GROUP BY because the day
+// boundaries depend on APP_TIMEZONE, which the databases don't agree on how
+// to apply.
+func (s *TaskService) CompletedReport(ctx context.Context, userID uint, from, to time.Time) ([]CompletedDay, error) {
+	var tasks []models.Task
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ? AND status = ?", userID, models.StatusCompleted).
+		Where("completed_at >= ? AND completed_at < ?", from, to).
+		Order("completed_at, id").
+		Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve completed tasks: %w", err)
+	}
+
+	loc := config.Location()
+	var days []CompletedDay
+	index := make(map[string]int)
+	for day := from.In(loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(completedDayLayout)
+		index[date] = len(days)
+		days = append(days, CompletedDay{Date: date, Tasks: []models.Task{}})
+	}
+
+	for _, task := range tasks {
+		i, ok := index[task.CompletedAt.In(loc).Format(completedDayLayout)]
+		if !ok {
+			continue
+		}
+		days[i].Tasks = append(days[i].Tasks, task)
+	}
+
+	return days, nil
+}
+
+// CompletionStreak summarizes the runs of consecutive days on which a user
+// completed at least one task
+type CompletionStreak struct {
+	// Current is the length of the run ending today, or yesterday if nothing
+	// has been completed today yet; zero if the run is broken
+	Current int
+	// Longest is the length of the longest run so far
+	Longest int
+	// TotalCompleted is how many of the user's tasks are completed
+	TotalCompleted int
+}
+
+// GetCompletionStreak computes the user's completion streaks as of now. Days
+// are cut in the configured time zone and several completions on one day
+// count once.
+func (s *TaskService) GetCompletionStreak(ctx context.Context, userID uint, now time.Time) (*CompletionStreak, error) {
+	var completed []time.Time
+	if err := s.db.WithContext(ctx).Model(&models.Task{}).
+		Where("user_id = ? AND status = ? AND completed_at IS NOT NULL", userID, models.StatusCompleted).
+		Order("completed_at").
+		Pluck("completed_at", &completed).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve completion times: %w", err)
+	}
+
+	streak := &CompletionStreak{TotalCompleted: len(completed)}
+	run, last := 0, int64(0)
+	for _, t := range completed {
+		day := dayNumber(t)
+		switch {
+		case run > 0 && day == last:
+			continue
+		case run > 0 && day == last+1:
+			run++
+		default:
+			run = 1
+		}
+		last = day
+		if run > streak.Longest {
+			streak.Longest = run
+		}
+	}
+
+	// The last run only counts as current if it reaches today or yesterday
+	if today := dayNumber(now); run > 0 && last >= today-1 {
+		streak.Current = run
+	}
+
+	return streak, nil
+}
+
+// dayNumber numbers the calendar day of t in the configured time zone, so
+// that consecutive days get consecutive numbers regardless of DST changes
+func dayNumber(t time.Time) int64 {
+	y, m, d := t.In(config.Location()).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60)
+}
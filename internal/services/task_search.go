@@ -0,0 +1,189 @@
+package services
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"task-manager/internal/models"
+)
+
+// Highlight markers wrapped around each match in a search snippet
+const (
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+)
+
+// Search modes. Substring search matches any part of the title or
+// description; fulltext search uses the MySQL FULLTEXT index in boolean mode
+// and falls back to substring search on other drivers.
+const (
+	SearchModeSubstring = "substring"
+	SearchModeFulltext  = "fulltext"
+)
+
+// fulltextMatch is the relevance of a task to a boolean-mode fulltext search,
+// zero if it doesn't match
+const fulltextMatch = "MATCH(title, description) AGAINST(? IN BOOLEAN MODE)"
+
+// snippetContext is how many characters of a description are kept on each
+// side of the first match
+const snippetContext = 40
+
+// TaskHighlight holds the search snippets of one task. A field is empty if
+// the search term doesn't occur in it.
+type TaskHighlight struct {
+	Title       string
+	Description string
+}
+
+// likeEscaper escapes LIKE wildcards using '!', an escape character that
+// needs no quoting in either MySQL or SQLite string literals
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// containsPattern returns a LIKE pattern matching values that contain term
+func containsPattern(term string) string {
+	return "%" + likeEscaper.Replace(term) + "%"
+}
+
+// searchTasks limits the query to tasks whose title or description contains
+// term. LIKE is case-insensitive under MySQL's default collations and for
+// ASCII in SQLite.
+func searchTasks(term string) func(*gorm.DB) *gorm.DB {
+	pattern := containsPattern(term)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("title LIKE ? ESCAPE '!' OR description LIKE ? ESCAPE '!'", pattern, pattern)
+	}
+}
+
+// titleMatchesFirst orders tasks whose title contains term before those that
+// only match in their description, then by the trusted ORDER BY list then
+func titleMatchesFirst(term, then string) clause.OrderBy {
+	return clause.OrderBy{
+		Expression: clause.Expr{
+			SQL:                "CASE WHEN title LIKE ? ESCAPE '!' THEN 0 ELSE 1 END, " + then,
+			Vars:               []interface{}{containsPattern(term)},
+			WithoutParentheses: true,
+		},
+	}
+}
+
+// usesFulltext reports whether db supports fulltext search
+func usesFulltext(db *gorm.DB) bool {
+	return db.Dialector.Name() == "mysql"
+}
+
+// matchTasks limits the query to tasks matching the fulltext search term
+func matchTasks(term string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(fulltextMatch, term)
+	}
+}
+
+// mostRelevantFirst orders tasks by their relevance to the fulltext search
+// term, then by the trusted ORDER BY list then
+func mostRelevantFirst(term, then string) clause.OrderBy {
+	return clause.OrderBy{
+		Expression: clause.Expr{
+			SQL:                fulltextMatch + " DESC, " + then,
+			Vars:               []interface{}{term},
+			WithoutParentheses: true,
+		},
+	}
+}
+
+// fulltextScores returns the relevance of each task to the fulltext search
+// term, keyed by task ID
+func fulltextScores(db *gorm.DB, tasks []models.Task, term string) (map[uint]float64, error) {
+	scores := make(map[uint]float64, len(tasks))
+	if len(tasks) == 0 {
+		return scores, nil
+	}
+
+	ids := make([]uint, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+
+	var rows []struct {
+		ID    uint
+		Score float64
+	}
+	if err := db.Model(&models.Task{}).
+		Select("id, "+fulltextMatch+" AS score", term).
+		Where("id IN ?", ids).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		scores[row.ID] = row.Score
+	}
+	return scores, nil
+}
+
+// highlightTasks returns the search snippets of each task, keyed by task ID
+func highlightTasks(tasks []models.Task, term string) map[uint]TaskHighlight {
+	re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(term))
+
+	highlights := make(map[uint]TaskHighlight, len(tasks))
+	for _, task := range tasks {
+		highlights[task.ID] = TaskHighlight{
+			Title:       highlight(re, task.Title, false),
+			Description: highlight(re, task.Description, true),
+		}
+	}
+	return highlights
+}
+
+// highlight returns text, HTML-escaped, with every match of re wrapped in
+// the highlight markers, or "" if nothing matches. With excerpt only the
+// part around the first match is kept.
+func highlight(re *regexp.Regexp, text string, excerpt bool) string {
+	first := re.FindStringIndex(text)
+	if first == nil {
+		return ""
+	}
+
+	prefix, suffix := "", ""
+	if excerpt {
+		start, end := first[0]-snippetContext, first[1]+snippetContext
+		if start > 0 {
+			start = runeStart(text, start)
+			prefix = "…"
+		} else {
+			start = 0
+		}
+		if end < len(text) {
+			end = runeStart(text, end)
+			suffix = "…"
+		} else {
+			end = len(text)
+		}
+		text = text[start:end]
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	last := 0
+	for _, match := range re.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:match[0]]))
+		b.WriteString(HighlightStart)
+		b.WriteString(html.EscapeString(text[match[0]:match[1]]))
+		b.WriteString(HighlightEnd)
+		last = match[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	b.WriteString(suffix)
+	return b.String()
+}
+
+// runeStart moves i back to the start of the UTF-8 sequence it falls in
+func runeStart(s string, i int) int {
+	for i > 0 && s[i]&0xC0 == 0x80 {
+		i--
+	}
+	return i
+}
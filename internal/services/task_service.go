@@ -1,12 +1,20 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/plugin/dbresolver"
 
+	"task-manager/config"
+	"task-manager/internal/apperrors"
 	"task-manager/internal/models"
 	"task-manager/pkg/database"
 )
@@ -16,8 +24,42 @@ type TaskRequest struct {
 	Title       string
 	Description string
 	DueDate     *time.Time
+	RemindAt    *time.Time
 	Priority    models.Priority
+	// Color is a #RRGGBB color, or empty for none
+	Color  string
+	UserID uint
+	// Version is the task version the client last saw; it is required when
+	// updating and ignored on create
+	Version int
+	// LastWriteWins lets an update without a Version overwrite the task
+	// whatever its current version, for clients of the unversioned API
+	LastWriteWins bool
+}
+
+// TaskPatch defines a partial update of a task. Nil fields and unset
+// OptionalTimes are left unchanged.
+type TaskPatch struct {
+	Title       *string
+	Description *string
+	DueDate     OptionalTime
+	RemindAt    OptionalTime
+	Priority    *models.Priority
+	Color       *string
 	UserID      uint
+	// Version is the task version the client last saw; it is required
+	// unless LastWriteWins is set
+	Version int
+	// LastWriteWins lets an update without a Version overwrite the task
+	// whatever its current version, for clients of the unversioned API
+	LastWriteWins bool
+}
+
+// OptionalTime is a timestamp in a partial update. When Set, the field is
+// changed to Time, with nil clearing it.
+type OptionalTime struct {
+	Set  bool
+	Time *time.Time
 }
 
 // TaskStatusRequest defines the data needed to update a task status
@@ -28,18 +70,70 @@ type TaskStatusRequest struct {
 
 // TaskFilterOptions defines the options for filtering and sorting tasks
 type TaskFilterOptions struct {
-	UserID   uint
-	Status   string
-	Priority string
-	SortBy   string
-	Order    string
-	Page     int
-	PageSize int
+	UserID uint
+	// AssignedToMe also includes tasks assigned to UserID
+	AssignedToMe bool
+	// SharedWithMe also includes tasks shared with UserID
+	SharedWithMe bool
+	Status       string
+	Priority     string
+	SortBy       string
+	Order        string
+	Page         int
+	PageSize     int
+	// Fields limits the columns loaded; empty loads them all
+	Fields []string
+	// Include names the relations to load with the tasks, e.g. IncludeUser
+	Include []string
+	// Search limits the tasks to those whose title or description contains
+	// it, ranking title matches first
+	Search string
+	// SearchMode is SearchModeSubstring (the default) or SearchModeFulltext,
+	// which ranks tasks by relevance and adds their scores to the response
+	SearchMode string
+	// Highlight adds search snippets to the response
+	Highlight bool
+	// DueAfter and DueBefore limit the tasks to those due in [DueAfter,
+	// DueBefore); tasks without a due date are excluded when either is set
+	DueAfter  *time.Time
+	DueBefore *time.Time
+	// Incomplete excludes completed tasks
+	Incomplete bool
+	// HasDueDate, if set, limits the tasks to those with (true) or without
+	// (false) a due date
+	HasDueDate *bool
+	// CreatedAfter and CreatedBefore limit the tasks to those created in
+	// [CreatedAfter, CreatedBefore)
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// Pinned, if set, limits the tasks to pinned (true) or unpinned (false)
+	// ones
+	Pinned *bool
+	// PinnedFirst lists pinned tasks before the others, each in the
+	// requested order
+	PinnedFirst bool
+	// IncludeArchived also includes archived tasks, which are left out
+	// by default
+	IncludeArchived bool
+}
+
+// PaginatedActivityResponse represents a paginated list of task activity
+type PaginatedActivityResponse struct {
+	Activities  []models.TaskActivity
+	CurrentPage int
+	PageSize    int
+	TotalItems  int64
+	TotalPages  int64
 }
 
 // PaginatedTasksResponse represents a paginated list of tasks
 type PaginatedTasksResponse struct {
-	Tasks       []models.Task
+	Tasks []models.Task
+	// Highlights holds search snippets keyed by task ID when requested
+	Highlights map[uint]TaskHighlight
+	// Scores holds the relevance of each task keyed by task ID when
+	// searching in fulltext mode
+	Scores      map[uint]float64
 	CurrentPage int
 	PageSize    int
 	TotalItems  int64
@@ -58,139 +152,853 @@ func NewTaskService() *TaskService {
 	}
 }
 
+// WithTx returns a copy of the service that runs its queries on tx,
+// allowing several operations to share one transaction
+func (s *TaskService) WithTx(tx *gorm.DB) *TaskService {
+	return &TaskService{
+		db: tx,
+	}
+}
+
+// WithPrimary returns a copy of the service whose reads are forced onto the
+// primary database. Use it when reading data that was just written, or before
+// a read-modify-write, so replication lag on the replicas can't be observed.
+func (s *TaskService) WithPrimary() *TaskService {
+	return &TaskService{
+		db: s.db.Clauses(dbresolver.Write).Session(&gorm.Session{}),
+	}
+}
+
+// ValidateTask checks the rules a new task must satisfy beyond those of the
+// request binding
+func (s *TaskService) ValidateTask(req TaskRequest) error {
+	if err := checkPriority(req.Priority); err != nil {
+		return err
+	}
+	if err := checkColor(req.Color); err != nil {
+		return err
+	}
+	return checkDueDate(req.DueDate, time.Now())
+}
+
+// checkPriority rejects an unknown priority. An empty priority means the
+// default or the current one is kept.
+func checkPriority(priority models.Priority) error {
+	if priority != "" && !priority.IsValid() {
+		return apperrors.ErrValidation.WithFields(map[string]string{"priority": "must be one of low, medium, high"})
+	}
+	return nil
+}
+
+// colorPattern matches a color in #RRGGBB form
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// checkColor rejects a color that isn't in #RRGGBB form. An empty color means
+// the task has none.
+func checkColor(color string) error {
+	if color != "" && !colorPattern.MatchString(color) {
+		return apperrors.ErrValidation.WithFields(map[string]string{"color": "must be a hex color like #1E90FF"})
+	}
+	return nil
+}
+
+// checkDueDate rejects a due date more than the grace period before now when
+// past due dates are disallowed
+func checkDueDate(dueDate *time.Time, now time.Time) error {
+	cfg := config.GetConfig().Tasks
+	if !cfg.RejectPastDueDates || dueDate == nil {
+		return nil
+	}
+	if dueDate.Before(now.Add(-cfg.DueDateGracePeriod)) {
+		return apperrors.ErrValidation.WithFields(map[string]string{"due_date": "must not be in the past"})
+	}
+	return nil
+}
+
 // CreateTask creates a new task for the user
-func (s *TaskService) CreateTask(req TaskRequest) (*models.Task, error) {
+func (s *TaskService) CreateTask(ctx context.Context, req TaskRequest) (*models.Task, error) {
+	if err := s.ValidateTask(req); err != nil {
+		return nil, err
+	}
+
 	task := models.Task{
 		UserID:      req.UserID,
 		Title:       req.Title,
 		Description: req.Description,
 		DueDate:     req.DueDate,
+		RemindAt:    req.RemindAt,
+		Color:       req.Color,
 		Status:      models.StatusTodo, // Default status is todo
 	}
 
-	// Set priority if provided, otherwise use default (medium)
+	// Set priority if provided, otherwise use the configured default
 	if req.Priority != "" {
 		task.Priority = req.Priority
 	} else {
-		task.Priority = models.PriorityMedium
+		task.Priority = models.Priority(config.GetConfig().Tasks.DefaultPriority)
 	}
 
-	// Save task to database
-	if err := s.db.Create(&task).Error; err != nil {
-		return nil, fmt.Errorf("failed to create task: %w", err)
+	// Check the limit and read the last position on the primary in the
+	// transaction that inserts the task. Locking the user's row makes
+	// concurrent creates for the user take turns, so that they can neither
+	// all pass the limit nor take the same position.
+	err := s.WithPrimary().db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockUser(tx, req.UserID); err != nil {
+			return err
+		}
+		if err := checkTaskLimit(tx, req.UserID); err != nil {
+			return err
+		}
+
+		// Append the task to the end of the user's manual order
+		last, err := lastPosition(tx, req.UserID)
+		if err != nil {
+			return err
+		}
+		task.Position = last + 1
+
+		// Save task to database
+		if err := tx.Create(&task).Error; err != nil {
+			return fmt.Errorf("failed to create task: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &task, nil
 }
 
-// GetTaskByID retrieves a task by ID if it belongs to the specified user
-func (s *TaskService) GetTaskByID(taskID uint, userID uint) (*models.Task, error) {
+// FindDuplicateTitle returns the oldest of the user's other open tasks whose
+// title matches task's, ignoring case and surrounding spaces, or nil if there
+// is none. Completed and archived tasks don't count.
+func (s *TaskService) FindDuplicateTitle(ctx context.Context, task *models.Task) (*models.Task, error) {
+	var duplicate models.Task
+	err := s.WithPrimary().db.WithContext(ctx).
+		Where("user_id = ? AND id <> ?", task.UserID, task.ID).
+		Where("status <> ? AND archived_at IS NULL", models.StatusCompleted).
+		Where("LOWER(TRIM(title)) = ?", strings.ToLower(strings.TrimSpace(task.Title))).
+		Order("id").
+		First(&duplicate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to look for duplicate tasks: %w", err)
+	}
+	return &duplicate, nil
+}
+
+// lockUser locks the user's row until the end of the transaction tx. SQLite
+// has no row locks; it serializes write transactions instead.
+func lockUser(tx *gorm.DB, userID uint) error {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrUserNotFound
+		}
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+	return nil
+}
+
+// checkTaskLimit rejects a new task for the user if they already own as
+// many tasks as MAX_TASKS_PER_USER allows
+func checkTaskLimit(db *gorm.DB, userID uint) error {
+	limit := config.GetConfig().Tasks.MaxTasksPerUser
+	if limit <= 0 {
+		return nil
+	}
+
+	var count int64
+	if err := db.Model(&models.Task{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count tasks: %w", err)
+	}
+	if count >= int64(limit) {
+		return apperrors.ErrTaskLimitReached.WithMessage(
+			fmt.Sprintf("You have %d tasks and at most %d are allowed; delete some before creating more", count, limit))
+	}
+	return nil
+}
+
+// CreateTaskIdempotent creates a task unless the user already created one
+// with the same idempotency key, in which case that task is returned and
+// replayed is true
+func (s *TaskService) CreateTaskIdempotent(ctx context.Context, req TaskRequest, key string) (task *models.Task, replayed bool, err error) {
+	requestHash, err := hashRequest(req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	db := s.db.WithContext(ctx)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		keys := NewIdempotencyService().WithTx(tx)
+		record, err := keys.Find(req.UserID, key, requestHash)
+		if err != nil {
+			return err
+		}
+		if record != nil {
+			task, err = s.WithTx(tx).GetTaskByID(ctx, record.TaskID, req.UserID)
+			replayed = true
+			return err
+		}
+
+		if task, err = s.WithTx(tx).CreateTask(ctx, req); err != nil {
+			return err
+		}
+		return keys.Save(req.UserID, key, requestHash, task.ID)
+	})
+	if err != nil && isDuplicateKeyError(err) {
+		// A concurrent request with the same key won the race; return its task
+		record, findErr := NewIdempotencyService().WithTx(db).Find(req.UserID, key, requestHash)
+		if findErr != nil {
+			return nil, false, findErr
+		}
+		if record != nil {
+			task, err = s.WithPrimary().GetTaskByID(ctx, record.TaskID, req.UserID)
+			return task, true, err
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return task, replayed, nil
+}
+
+// GetTaskByID retrieves a task by ID if the user owns it, or it is shared
+// with or assigned to them
+func (s *TaskService) GetTaskByID(ctx context.Context, taskID uint, userID uint) (*models.Task, error) {
+	return s.GetTaskFields(ctx, taskID, userID, nil, nil)
+}
+
+// GetTaskFields is like GetTaskByID but loads only the given columns, or all
+// of them if fields is empty, and the relations named in include
+func (s *TaskService) GetTaskFields(ctx context.Context, taskID uint, userID uint, fields []string, include []string) (*models.Task, error) {
+	query := s.db.WithContext(ctx).Scopes(preloadRelations(include))
+	if len(fields) > 0 {
+		// The owner and assignee are needed to check access
+		columns := withForeignKeys(fields, include)
+		for _, column := range []string{"user_id", "assignee_id"} {
+			if !containsString(columns, column) {
+				columns = append(columns[:len(columns):len(columns)], column)
+			}
+		}
+		query = query.Select(columns)
+	}
+
 	var task models.Task
-	result := s.db.Where("id = ? AND user_id = ?", taskID, userID).First(&task)
+	result := query.Where("id = ?", taskID).First(&task)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, errors.New("task not found")
+			return nil, ErrTaskNotFound
 		}
 		return nil, fmt.Errorf("failed to retrieve task: %w", result.Error)
 	}
+	if err := s.checkAccess(ctx, taskID, task.UserID, task.AssigneeID, userID, actionView); err != nil {
+		return nil, err
+	}
 	return &task, nil
 }
 
-// UpdateTask updates an existing task if it belongs to the specified user
-func (s *TaskService) UpdateTask(taskID uint, req TaskRequest) (*models.Task, error) {
-	// Find task by ID and ensure it belongs to the user
-	task, err := s.GetTaskByID(taskID, req.UserID)
+// GetTasksByIDs returns the tasks among taskIDs that the user owns, or that
+// are shared with or assigned to them, in the order of taskIDs. IDs of other
+// users' tasks and of tasks that don't exist are skipped.
+func (s *TaskService) GetTasksByIDs(ctx context.Context, taskIDs []uint, userID uint) ([]models.Task, error) {
+	var found []models.Task
+	if err := s.filteredTasks(ctx, TaskFilterOptions{
+		UserID:          userID,
+		AssignedToMe:    true,
+		SharedWithMe:    true,
+		IncludeArchived: true,
+	}).Where("id IN (?)", taskIDs).Find(&found).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve tasks: %w", err)
+	}
+
+	byID := make(map[uint]models.Task, len(found))
+	for _, task := range found {
+		byID[task.ID] = task
+	}
+	tasks := make([]models.Task, 0, len(found))
+	for _, id := range taskIDs {
+		if task, ok := byID[id]; ok {
+			tasks = append(tasks, task)
+			delete(byID, id)
+		}
+	}
+	return tasks, nil
+}
+
+// UpdateTask replaces the details of an existing task if it belongs to the
+// specified user: a nil DueDate or RemindAt clears it. Use PatchTask to leave
+// fields unchanged. req.Version must be the version the client last read;
+// ErrVersionRequired is returned without it unless req.LastWriteWins is set.
+func (s *TaskService) UpdateTask(ctx context.Context, taskID uint, req TaskRequest) (*models.Task, error) {
+	if req.Version == 0 && !req.LastWriteWins {
+		return nil, apperrors.ErrVersionRequired
+	}
+
+	// Find task by ID and ensure the user may edit it
+	task, err := s.WithPrimary().authorize(ctx, taskID, req.UserID, actionEdit)
 	if err != nil {
 		return nil, err
 	}
+	before := *task
+
+	if err := checkPriority(req.Priority); err != nil {
+		return nil, err
+	}
+	if err := checkColor(req.Color); err != nil {
+		return nil, err
+	}
+
+	// An overdue task can still be edited as long as its due date is kept
+	if !sameTime(task.DueDate, req.DueDate) {
+		if err := checkDueDate(req.DueDate, time.Now()); err != nil {
+			return nil, err
+		}
+	}
 
 	// Update task fields
 	task.Title = req.Title
 	task.Description = req.Description
 	task.DueDate = req.DueDate
+	task.Color = req.Color
 	if req.Priority != "" {
 		task.Priority = req.Priority
 	}
 
-	// Save updated task
-	if err := s.db.Save(task).Error; err != nil {
-		return nil, fmt.Errorf("failed to update task: %w", err)
+	// Re-arm the reminder when its time changes
+	if !sameTime(task.RemindAt, req.RemindAt) {
+		task.RemindAt = req.RemindAt
+		task.RemindedAt = nil
+	}
+
+	// Save only if nobody else changed the task since the client read it
+	if err := s.saveChanges(ctx, before, task, expectedVersion(req.Version, before), req.UserID); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// PatchTask updates the fields of a task set in patch, leaving the others
+// unchanged, if the task belongs to the specified user. Like UpdateTask it
+// requires patch.Version unless patch.LastWriteWins is set.
+func (s *TaskService) PatchTask(ctx context.Context, taskID uint, patch TaskPatch) (*models.Task, error) {
+	if patch.Version == 0 && !patch.LastWriteWins {
+		return nil, apperrors.ErrVersionRequired
+	}
+
+	// Find task by ID and ensure the user may edit it
+	task, err := s.WithPrimary().authorize(ctx, taskID, patch.UserID, actionEdit)
+	if err != nil {
+		return nil, err
+	}
+	before := *task
+
+	if patch.Title != nil {
+		task.Title = *patch.Title
+	}
+	if patch.Description != nil {
+		task.Description = *patch.Description
+	}
+	if patch.DueDate.Set && !sameTime(task.DueDate, patch.DueDate.Time) {
+		if err := checkDueDate(patch.DueDate.Time, time.Now()); err != nil {
+			return nil, err
+		}
+		task.DueDate = patch.DueDate.Time
+	}
+	if patch.Priority != nil {
+		if !patch.Priority.IsValid() {
+			return nil, apperrors.ErrValidation.WithFields(map[string]string{"priority": "must be one of low, medium, high"})
+		}
+		task.Priority = *patch.Priority
+	}
+	if patch.Color != nil {
+		if err := checkColor(*patch.Color); err != nil {
+			return nil, err
+		}
+		task.Color = *patch.Color
+	}
+
+	// Re-arm the reminder when its time changes
+	if patch.RemindAt.Set && !sameTime(task.RemindAt, patch.RemindAt.Time) {
+		task.RemindAt = patch.RemindAt.Time
+		task.RemindedAt = nil
+	}
+
+	// Save only if nobody else changed the task since the client read it
+	if err := s.saveChanges(ctx, before, task, expectedVersion(patch.Version, before), patch.UserID); err != nil {
+		return nil, err
 	}
 
 	return task, nil
 }
 
 // UpdateTaskStatus updates only the status of a task
-func (s *TaskService) UpdateTaskStatus(taskID uint, req TaskStatusRequest) (*models.Task, error) {
-	// Find task by ID and ensure it belongs to the user
-	task, err := s.GetTaskByID(taskID, req.UserID)
+func (s *TaskService) UpdateTaskStatus(ctx context.Context, taskID uint, req TaskStatusRequest) (*models.Task, error) {
+	if !req.Status.IsValid() {
+		return nil, apperrors.ErrValidation.WithFields(map[string]string{"status": "must be one of todo, in_progress, completed"})
+	}
+
+	// Find task by ID and ensure the user may edit it
+	task, err := s.WithPrimary().authorize(ctx, taskID, req.UserID, actionEdit)
 	if err != nil {
 		return nil, err
 	}
 
-	// Update task status
+	// A task can't be completed while tasks it depends on are still open
+	if req.Status == models.StatusCompleted && task.Status != models.StatusCompleted {
+		if err := s.WithPrimary().checkDependenciesCompleted(ctx, task.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Update task status, remembering when the task was completed
+	before := *task
 	task.Status = req.Status
+	if req.Status != models.StatusCompleted {
+		task.CompletedAt = nil
+	} else if before.Status != models.StatusCompleted {
+		now := time.Now()
+		task.CompletedAt = &now
+	}
 
 	// Save updated task
-	if err := s.db.Save(task).Error; err != nil {
-		return nil, fmt.Errorf("failed to update task status: %w", err)
+	if err := s.saveChanges(ctx, before, task, task.Version, req.UserID); err != nil {
+		return nil, err
 	}
 
 	return task, nil
 }
 
-// DeleteTask deletes a task if it belongs to the specified user
-func (s *TaskService) DeleteTask(taskID uint, userID uint) error {
-	// Find task by ID and ensure it belongs to the user
-	task, err := s.GetTaskByID(taskID, userID)
+// PinTask pins or unpins a task if it belongs to the specified user
+func (s *TaskService) PinTask(ctx context.Context, taskID uint, userID uint, pinned bool) (*models.Task, error) {
+	task, err := s.WithPrimary().authorize(ctx, taskID, userID, actionManage)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if task.Pinned == pinned {
+		return task, nil
+	}
+
+	before := *task
+	task.Pinned = pinned
+	if err := s.saveChanges(ctx, before, task, task.Version, userID); err != nil {
+		return nil, err
 	}
 
-	// Delete the task (soft delete with GORM)
-	if err := s.db.Delete(task).Error; err != nil {
-		return fmt.Errorf("failed to delete task: %w", err)
+	return task, nil
+}
+
+// SnoozeTask moves the due date of a task forward to until if the task
+// belongs to the specified user. A reminder is moved by as much as the due
+// date, or by the time from now to until if the task had no due date, and is
+// re-armed.
+func (s *TaskService) SnoozeTask(ctx context.Context, taskID uint, userID uint, until time.Time) (*models.Task, error) {
+	task, err := s.WithPrimary().authorize(ctx, taskID, userID, actionEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	from := time.Now()
+	if task.DueDate != nil {
+		from = *task.DueDate
+	}
+	if !until.After(from) {
+		return nil, apperrors.ErrValidation.WithFields(map[string]string{"due_date": "must be later than the current due date"})
+	}
+
+	before := *task
+	task.DueDate = &until
+	if task.RemindAt != nil {
+		remindAt := task.RemindAt.Add(until.Sub(from))
+		task.RemindAt = &remindAt
+		task.RemindedAt = nil
+	}
+	if err := s.saveChanges(ctx, before, task, task.Version, userID); err != nil {
+		return nil, err
 	}
 
+	return task, nil
+}
+
+// ArchiveTask archives a task if it belongs to the specified user, hiding it
+// from task lists without deleting it. Archiving an archived task keeps
+// the original time.
+func (s *TaskService) ArchiveTask(ctx context.Context, taskID uint, userID uint) (*models.Task, error) {
+	return s.setArchived(ctx, taskID, userID, true)
+}
+
+// UnarchiveTask returns an archived task to the task lists if it belongs to
+// the specified user
+func (s *TaskService) UnarchiveTask(ctx context.Context, taskID uint, userID uint) (*models.Task, error) {
+	return s.setArchived(ctx, taskID, userID, false)
+}
+
+// setArchived archives or unarchives a task, leaving it unchanged if it is
+// already in that state
+func (s *TaskService) setArchived(ctx context.Context, taskID uint, userID uint, archived bool) (*models.Task, error) {
+	task, err := s.WithPrimary().authorize(ctx, taskID, userID, actionManage)
+	if err != nil {
+		return nil, err
+	}
+	if (task.ArchivedAt != nil) == archived {
+		return task, nil
+	}
+
+	before := *task
+	task.ArchivedAt = nil
+	if archived {
+		now := time.Now()
+		task.ArchivedAt = &now
+	}
+	if err := s.saveChanges(ctx, before, task, task.Version, userID); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// expectedVersion returns the version an update must find: the client's
+// version if it sent one, and otherwise the version just loaded
+func expectedVersion(version int, loaded models.Task) int {
+	if version == 0 {
+		return loaded.Version
+	}
+	return version
+}
+
+// saveVersioned writes all of task's fields if the stored row still has the
+// expected version, incrementing the version. Returns ErrVersionConflict if
+// the task was changed concurrently.
+func (s *TaskService) saveVersioned(ctx context.Context, task *models.Task, expected int) error {
+	task.Version = expected + 1
+	result := s.db.WithContext(ctx).Model(task).
+		Where("version = ?", expected).
+		Select("*").
+		Omit(clause.Associations).
+		Updates(task)
+	if result.Error != nil {
+		task.Version = expected
+		return fmt.Errorf("failed to update task: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		task.Version = expected
+		return apperrors.ErrVersionConflict
+	}
 	return nil
 }
 
-// GetTasks retrieves tasks with pagination, filtering, and sorting
-func (s *TaskService) GetTasks(options TaskFilterOptions) (*PaginatedTasksResponse, error) {
-	// Set default pagination values if not provided
-	page := options.Page
-	if page < 1 {
-		page = 1
+// AssignTask assigns a task owned by ownerID to the user with the given email
+func (s *TaskService) AssignTask(ctx context.Context, taskID uint, ownerID uint, email string) (*models.Task, error) {
+	task, err := s.WithPrimary().authorize(ctx, taskID, ownerID, actionManage)
+	if err != nil {
+		return nil, err
 	}
 
-	pageSize := options.PageSize
-	if pageSize < 1 {
-		pageSize = 10
-	} else if pageSize > 100 {
-		pageSize = 100
+	var assignee models.User
+	if err := s.db.WithContext(ctx).Where("email = ?", email).First(&assignee).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to find assignee: %w", err)
 	}
 
-	// Calculate offset
-	offset := (page - 1) * pageSize
+	before := *task
+	task.AssigneeID = &assignee.ID
+	if err := s.saveChanges(ctx, before, task, task.Version, ownerID); err != nil {
+		return nil, err
+	}
 
-	// Start building the query
-	query := s.db.Model(&models.Task{}).Where("user_id = ?", options.UserID)
+	return task, nil
+}
 
-	// Apply filters if provided
-	if options.Status != "" {
-		query = query.Where("status = ?", options.Status)
+// TransferOwnership moves all of one user's tasks to another user, e.g. when
+// offboarding, and returns how many tasks were moved
+func (s *TaskService) TransferOwnership(ctx context.Context, fromUserID, toUserID uint) (int64, error) {
+	if fromUserID == toUserID {
+		return 0, apperrors.ErrBadRequest.WithMessage("Cannot transfer tasks to the same user")
 	}
-	if options.Priority != "" {
-		query = query.Where("priority = ?", options.Priority)
+
+	var moved int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, userID := range []uint{fromUserID, toUserID} {
+			var count int64
+			if err := tx.Model(&models.User{}).Where("id = ?", userID).Count(&count).Error; err != nil {
+				return fmt.Errorf("failed to find user: %w", err)
+			}
+			if count == 0 {
+				return apperrors.ErrUserNotFound.WithMessage(fmt.Sprintf("User %d not found", userID))
+			}
+		}
+
+		// Place the moved tasks after the recipient's own in the manual order
+		last, err := lastPosition(tx, toUserID)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Task{}).Where("user_id = ?", fromUserID).Updates(map[string]interface{}{
+			"user_id":  toUserID,
+			"position": gorm.Expr("position + ?", last),
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to transfer tasks: %w", result.Error)
+		}
+		moved = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return moved, nil
+}
+
+// DeleteTask deletes a task if it belongs to the specified user. Tasks are
+// soft-deleted unless permanent is set, see purgeTask.
+func (s *TaskService) DeleteTask(ctx context.Context, taskID uint, userID uint, permanent bool) error {
+	if permanent {
+		return s.purgeTask(ctx, taskID, userID)
+	}
+
+	// Find task by ID and ensure the user owns it
+	task, err := s.WithPrimary().authorize(ctx, taskID, userID, actionManage)
+	if err != nil {
+		return err
+	}
+
+	// Delete the task (soft delete with GORM) and record who deleted it
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(task).Error; err != nil {
+			return fmt.Errorf("failed to delete task: %w", err)
+		}
+		return s.WithTx(tx).recordActivity(ctx, []models.TaskActivity{{
+			TaskID: task.ID,
+			UserID: userID,
+			Action: models.ActivityDeleted,
+		}})
+	})
+}
+
+// purgeTask removes a task, its activity log and its dependency links from
+// the database so that nothing of it can be recovered. It also purges tasks
+// that were already soft-deleted. Admins may purge any user's task; others
+// only their own.
+func (s *TaskService) purgeTask(ctx context.Context, taskID uint, userID uint) error {
+	db := s.db.Clauses(dbresolver.Write).WithContext(ctx)
+
+	var task models.Task
+	if err := db.Unscoped().First(&task, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTaskNotFound
+		}
+		return fmt.Errorf("failed to retrieve task: %w", err)
+	}
+
+	if task.UserID != userID {
+		var actor models.User
+		if err := db.First(&actor, userID).Error; err != nil {
+			return fmt.Errorf("failed to retrieve user: %w", err)
+		}
+		// Don't reveal that another user's task exists
+		if actor.Role != models.RoleAdmin {
+			return ErrTaskNotFound
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// The activity log holds old titles and descriptions, so it goes too
+		if err := tx.Where("task_id = ?", task.ID).Delete(&models.TaskActivity{}).Error; err != nil {
+			return fmt.Errorf("failed to delete task activity: %w", err)
+		}
+		if err := tx.Where("task_id = ? OR depends_on_id = ?", task.ID, task.ID).Delete(&models.TaskDependency{}).Error; err != nil {
+			return fmt.Errorf("failed to delete task dependencies: %w", err)
+		}
+		if err := tx.Where("task_id = ?", task.ID).Delete(&models.TaskCollaborator{}).Error; err != nil {
+			return fmt.Errorf("failed to delete task collaborators: %w", err)
+		}
+		if err := tx.Unscoped().Delete(&task).Error; err != nil {
+			return fmt.Errorf("failed to delete task: %w", err)
+		}
+		return nil
+	})
+}
+
+// PurgeDeletedTasks removes the tasks soft-deleted before cutoff from the
+// database, together with their activity logs and dependency links, and
+// returns how many tasks were removed
+func (s *TaskService) PurgeDeletedTasks(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deleted := tx.Unscoped().Model(&models.Task{}).Select("id").Where("deleted_at < ?", cutoff)
+		if err := tx.Where("task_id IN (?)", deleted).Delete(&models.TaskActivity{}).Error; err != nil {
+			return fmt.Errorf("failed to delete task activity: %w", err)
+		}
+		if err := tx.Where("task_id IN (?) OR depends_on_id IN (?)", deleted, deleted).Delete(&models.TaskDependency{}).Error; err != nil {
+			return fmt.Errorf("failed to delete task dependencies: %w", err)
+		}
+		if err := tx.Where("task_id IN (?)", deleted).Delete(&models.TaskCollaborator{}).Error; err != nil {
+			return fmt.Errorf("failed to delete task collaborators: %w", err)
+		}
+		result := tx.Unscoped().Where("deleted_at < ?", cutoff).Delete(&models.Task{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to purge deleted tasks: %w", result.Error)
+		}
+		purged = result.RowsAffected
+		return nil
+	})
+	return purged, err
+}
+
+// DeleteByFilter soft-deletes all of the user's tasks matching the status
+// and priority filters and returns how many were deleted. At least one
+// filter is required so that a missing parameter can't delete every task.
+// With dryRun the matching tasks are only counted.
+func (s *TaskService) DeleteByFilter(ctx context.Context, options TaskFilterOptions, dryRun bool) (int64, error) {
+	db := s.db.WithContext(ctx)
+
+	if options.Status == "" && options.Priority == "" {
+		return 0, apperrors.ErrBadRequest.WithMessage("At least one filter (status or priority) is required")
+	}
+
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("user_id = ?", options.UserID)
+		if options.Status != "" {
+			db = db.Where("status = ?", options.Status)
+		}
+		if options.Priority != "" {
+			db = db.Where("priority = ?", options.Priority)
+		}
+		return db
+	}
+
+	if dryRun {
+		var count int64
+		if err := db.Model(&models.Task{}).Scopes(scope).Count(&count).Error; err != nil {
+			return 0, fmt.Errorf("failed to count tasks: %w", err)
+		}
+		return count, nil
+	}
+
+	var deleted int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Model(&models.Task{}).Scopes(scope).Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("failed to find tasks: %w", err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		result := tx.Scopes(scope).Where("id IN ?", ids).Delete(&models.Task{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete tasks: %w", result.Error)
+		}
+		deleted = result.RowsAffected
+
+		activities := make([]models.TaskActivity, len(ids))
+		for i, id := range ids {
+			activities[i] = models.TaskActivity{
+				TaskID: id,
+				UserID: options.UserID,
+				Action: models.ActivityDeleted,
+			}
+		}
+		return s.WithTx(tx).recordActivity(ctx, activities)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// GetTaskActivity retrieves the change history of a task the user may view,
+// newest first. The history of deleted tasks remains available.
+func (s *TaskService) GetTaskActivity(ctx context.Context, taskID uint, userID uint, page, pageSize int) (*PaginatedActivityResponse, error) {
+	db := s.db.WithContext(ctx)
+
+	var task models.Task
+	if err := db.Unscoped().Select("id", "user_id", "assignee_id").First(&task, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("failed to retrieve task: %w", err)
+	}
+	if err := s.checkAccess(ctx, task.ID, task.UserID, task.AssigneeID, userID, actionView); err != nil {
+		return nil, err
+	}
+
+	page, pageSize = normalizePagination(page, pageSize)
+	query := db.Model(&models.TaskActivity{}).Where("task_id = ?", taskID)
+
+	var totalItems int64
+	if err := query.Count(&totalItems).Error; err != nil {
+		return nil, fmt.Errorf("failed to count task activity: %w", err)
+	}
+	page, totalPages := clampPage(page, pageSize, totalItems)
+
+	var activities []models.TaskActivity
+	if err := query.Order("created_at desc, id desc").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&activities).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve task activity: %w", err)
 	}
 
+	return &PaginatedActivityResponse{
+		Activities:  activities,
+		CurrentPage: page,
+		PageSize:    pageSize,
+		TotalItems:  totalItems,
+		TotalPages:  totalPages,
+	}, nil
+}
+
+// saveChanges saves task with optimistic locking and records an activity
+// entry for each field that differs from before, in one transaction
+func (s *TaskService) saveChanges(ctx context.Context, before models.Task, task *models.Task, expected int, userID uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.WithTx(tx).saveVersioned(ctx, task, expected); err != nil {
+			return err
+		}
+		return s.WithTx(tx).recordActivity(ctx, taskChanges(before, *task, userID))
+	})
+}
+
+// recordActivity stores task activity entries
+func (s *TaskService) recordActivity(ctx context.Context, activities []models.TaskActivity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+	if err := s.db.WithContext(ctx).Create(&activities).Error; err != nil {
+		return fmt.Errorf("failed to record task activity: %w", err)
+	}
+	return nil
+}
+
+// GetTasks retrieves tasks with pagination, filtering, and sorting
+func (s *TaskService) GetTasks(ctx context.Context, options TaskFilterOptions) (*PaginatedTasksResponse, error) {
+	// Set default pagination values if not provided
+	page, pageSize := normalizePagination(options.Page, options.PageSize)
+
+	// Start building the query
+	query := s.filteredTasks(ctx, options)
+
 	// Determine sorting
 	sortBy := "created_at" // default sort field
 	if options.SortBy != "" {
 		sortBy = options.SortBy
 	}
 
-	order := "desc" // default order
+	order := "desc" // default order, except for the manual order
+	if sortBy == "position" {
+		order = "asc"
+	}
 	if options.Order != "" {
 		order = options.Order
 	}
@@ -201,24 +1009,220 @@ func (s *TaskService) GetTasks(options TaskFilterOptions) (*PaginatedTasksRespon
 		return nil, fmt.Errorf("failed to count tasks: %w", err)
 	}
 
-	// Apply sorting, pagination, and execute query
+	// Serve the last page for a page past the end
+	page, totalPages := clampPage(page, pageSize, totalTasks)
+	offset := (page - 1) * pageSize
+
+	// Load only the requested columns, if any, and the requested relations
+	if len(options.Fields) > 0 {
+		query = query.Select(withForeignKeys(options.Fields, options.Include))
+	}
+	query = query.Scopes(preloadRelations(options.Include))
+
+	if options.PinnedFirst {
+		query = query.Order("pinned desc")
+	}
+
+	// Rank the most relevant tasks first in fulltext mode, and title
+	// matches above description matches in substring mode
+	switch {
+	case s.fulltextSearch(options):
+		query = query.Order(mostRelevantFirst(options.Search, sortBy+" "+order))
+	case options.Search != "":
+		query = query.Order(titleMatchesFirst(options.Search, sortBy+" "+order))
+	default:
+		query = query.Order(sortBy + " " + order)
+	}
+
+	// Apply pagination and execute query
 	var tasks []models.Task
-	if err := query.Order(sortBy + " " + order).
+	if err := query.
 		Limit(pageSize).
 		Offset(offset).
 		Find(&tasks).Error; err != nil {
 		return nil, fmt.Errorf("failed to retrieve tasks: %w", err)
 	}
 
-	// Calculate total pages
-	totalPages := (totalTasks + int64(pageSize) - 1) / int64(pageSize)
+	var highlights map[uint]TaskHighlight
+	if options.Highlight && options.Search != "" {
+		highlights = highlightTasks(tasks, options.Search)
+	}
+
+	var scores map[uint]float64
+	if s.fulltextSearch(options) {
+		var err error
+		if scores, err = fulltextScores(s.db.WithContext(ctx), tasks, options.Search); err != nil {
+			return nil, fmt.Errorf("failed to score tasks: %w", err)
+		}
+	}
 
 	// Return response with pagination metadata
 	return &PaginatedTasksResponse{
 		Tasks:       tasks,
+		Highlights:  highlights,
+		Scores:      scores,
 		CurrentPage: page,
 		PageSize:    pageSize,
 		TotalItems:  totalTasks,
 		TotalPages:  totalPages,
 	}, nil
-}
\ No newline at end of file
+}
+
+// CountTasks returns how many tasks match the filters of options; sorting
+// and pagination are ignored
+func (s *TaskService) CountTasks(ctx context.Context, options TaskFilterOptions) (int64, error) {
+	var count int64
+	if err := s.filteredTasks(ctx, options).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count tasks: %w", err)
+	}
+	return count, nil
+}
+
+// filteredTasks returns a task query limited by the filters of options
+func (s *TaskService) filteredTasks(ctx context.Context, options TaskFilterOptions) *gorm.DB {
+	query := s.db.WithContext(ctx).Model(&models.Task{})
+	owned := []string{"user_id = ?"}
+	args := []interface{}{options.UserID}
+	if options.AssignedToMe {
+		owned = append(owned, "assignee_id = ?")
+		args = append(args, options.UserID)
+	}
+	if options.SharedWithMe {
+		owned = append(owned, "id IN (?)")
+		args = append(args, sharedTaskIDs(s.db, options.UserID))
+	}
+	query = query.Where(strings.Join(owned, " OR "), args...)
+
+	// Apply filters if provided
+	if options.Status != "" {
+		query = query.Where("status = ?", options.Status)
+	}
+	if options.Priority != "" {
+		query = query.Where("priority = ?", options.Priority)
+	}
+	if options.Search != "" {
+		if s.fulltextSearch(options) {
+			query = query.Scopes(matchTasks(options.Search))
+		} else {
+			query = query.Scopes(searchTasks(options.Search))
+		}
+	}
+	if options.DueAfter != nil {
+		query = query.Where("due_date >= ?", *options.DueAfter)
+	}
+	if options.DueBefore != nil {
+		query = query.Where("due_date < ?", *options.DueBefore)
+	}
+	if options.Incomplete {
+		query = query.Where("status <> ?", models.StatusCompleted)
+	}
+	if options.HasDueDate != nil {
+		if *options.HasDueDate {
+			query = query.Where("due_date IS NOT NULL")
+		} else {
+			query = query.Where("due_date IS NULL")
+		}
+	}
+	// created_at is stored in the server's zone; SQLite compares timestamps
+	// as text, so the bounds have to be given in the same zone
+	if options.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", options.CreatedAfter.Local())
+	}
+	if options.CreatedBefore != nil {
+		query = query.Where("created_at < ?", options.CreatedBefore.Local())
+	}
+	if options.Pinned != nil {
+		query = query.Where("pinned = ?", *options.Pinned)
+	}
+	if !options.IncludeArchived {
+		query = query.Where("archived_at IS NULL")
+	}
+
+	return query
+}
+
+// fulltextSearch reports whether options ask for a fulltext search that the
+// database can run; otherwise substring search is used
+func (s *TaskService) fulltextSearch(options TaskFilterOptions) bool {
+	return options.Search != "" && options.SearchMode == SearchModeFulltext && usesFulltext(s.db)
+}
+
+// normalizePagination applies the default page and configured default page
+// size, and caps the page size at the configured maximum
+func normalizePagination(page, pageSize int) (int, int) {
+	cfg := config.GetConfig().Pagination
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = cfg.DefaultPageSize
+	} else if pageSize > cfg.MaxPageSize {
+		pageSize = cfg.MaxPageSize
+	}
+	return page, pageSize
+}
+
+// clampPage returns the number of pages needed for totalItems and page
+// clamped to the last of them. An empty list still has one (empty) page so
+// that the current page never exceeds the page count.
+func clampPage(page, pageSize int, totalItems int64) (int, int64) {
+	totalPages := max((totalItems+int64(pageSize)-1)/int64(pageSize), 1)
+	if int64(page) > totalPages {
+		page = int(totalPages)
+	}
+	return page, totalPages
+}
+
+// taskChanges returns an activity entry for each tracked field that differs
+// between before and after
+func taskChanges(before, after models.Task, userID uint) []models.TaskActivity {
+	var activities []models.TaskActivity
+	add := func(action models.ActivityAction, oldValue, newValue string) {
+		if oldValue != newValue {
+			activities = append(activities, models.TaskActivity{
+				TaskID:   after.ID,
+				UserID:   userID,
+				Action:   action,
+				OldValue: oldValue,
+				NewValue: newValue,
+			})
+		}
+	}
+
+	add(models.ActivityTitleChanged, before.Title, after.Title)
+	add(models.ActivityDescriptionChanged, before.Description, after.Description)
+	add(models.ActivityDueDateChanged, formatTime(before.DueDate), formatTime(after.DueDate))
+	add(models.ActivityReminderChanged, formatTime(before.RemindAt), formatTime(after.RemindAt))
+	add(models.ActivityPriorityChanged, string(before.Priority), string(after.Priority))
+	add(models.ActivityStatusChanged, string(before.Status), string(after.Status))
+	add(models.ActivityAssigneeChanged, formatID(before.AssigneeID), formatID(after.AssigneeID))
+	add(models.ActivityColorChanged, before.Color, after.Color)
+	add(models.ActivityPinnedChanged, strconv.FormatBool(before.Pinned), strconv.FormatBool(after.Pinned))
+	add(models.ActivityArchivedChanged, formatTime(before.ArchivedAt), formatTime(after.ArchivedAt))
+
+	return activities
+}
+
+// formatTime formats an optional timestamp for the activity log
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.In(config.Location()).Format(time.RFC3339)
+}
+
+// formatID formats an optional ID for the activity log
+func formatID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+// sameTime reports whether two optional timestamps are equal
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"task-manager/config"
+	"task-manager/internal/apperrors"
+	"task-manager/internal/models"
+)
+
+// createTask creates a task for the user through the service
+func createTask(t *testing.T, userID uint, title string) *models.Task {
+	t.Helper()
+	task, err := NewTaskService().CreateTask(context.Background(), TaskRequest{
+		Title:    title,
+		Priority: models.PriorityMedium,
+		UserID:   userID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+func TestCreateTaskAppendsPosition(t *testing.T) {
+	db := newTestDB(t)
+	alice := seedUser(t, db, "alice")
+	bob := seedUser(t, db, "bob")
+
+	for i, want := range []int{1, 2, 3} {
+		if task := createTask(t, alice.ID, "Task"); task.Position != want {
+			t.Errorf("task %d got position %d, want %d", i+1, task.Position, want)
+		}
+	}
+	// Positions are per user
+	if task := createTask(t, bob.ID, "Task"); task.Position != 1 {
+		t.Errorf("first task of another user got position %d, want 1", task.Position)
+	}
+}
+
+func TestCreateTaskLimit(t *testing.T) {
+	db := newTestDB(t)
+	alice := seedUser(t, db, "alice")
+
+	limits := &config.GetConfig().Tasks
+	defer func(max int) { limits.MaxTasksPerUser = max }(limits.MaxTasksPerUser)
+	limits.MaxTasksPerUser = 5
+
+	var wg sync.WaitGroup
+	errs := make([]error, 20)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = NewTaskService().CreateTask(context.Background(), TaskRequest{Title: "Task", UserID: alice.ID})
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, apperrors.ErrTaskLimitReached):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	var count int64
+	if err := db.Model(&models.Task{}).Where("user_id = ?", alice.ID).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if created != 5 || count != 5 {
+		t.Errorf("created %d tasks and stored %d, want the limit of 5", created, count)
+	}
+}
+
+func TestTaskServiceLifecycle(t *testing.T) {
+	db := newTestDB(t)
+	alice := seedUser(t, db, "alice")
+	bob := seedUser(t, db, "bob")
+	ctx := context.Background()
+	service := NewTaskService()
+
+	task := createTask(t, alice.ID, "Write report")
+
+	if _, err := service.GetTaskByID(ctx, task.ID, bob.ID); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("another user's read: got %v, want ErrTaskNotFound", err)
+	}
+
+	updated, err := service.UpdateTask(ctx, task.ID, TaskRequest{
+		Title:    "Write the report",
+		Priority: models.PriorityHigh,
+		UserID:   alice.ID,
+		Version:  task.Version,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Title != "Write the report" || updated.Priority != models.PriorityHigh || updated.Version != task.Version+1 {
+		t.Errorf("got %q, %s at version %d after the update", updated.Title, updated.Priority, updated.Version)
+	}
+	if _, err := service.UpdateTask(ctx, task.ID, TaskRequest{Title: "Bob's", UserID: bob.ID, Version: updated.Version}); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("another user's update: got %v, want ErrTaskNotFound", err)
+	}
+
+	completed, err := service.UpdateTaskStatus(ctx, task.ID, TaskStatusRequest{Status: models.StatusCompleted, UserID: alice.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completed.Status != models.StatusCompleted || completed.CompletedAt == nil {
+		t.Errorf("got status %s, completed at %v, want completed with a time", completed.Status, completed.CompletedAt)
+	}
+
+	if err := service.DeleteTask(ctx, task.ID, bob.ID, false); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("another user's delete: got %v, want ErrTaskNotFound", err)
+	}
+	if err := service.DeleteTask(ctx, task.ID, alice.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.GetTaskByID(ctx, task.ID, alice.ID); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("read after delete: got %v, want ErrTaskNotFound", err)
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"task-manager/internal/apperrors"
+	"task-manager/internal/models"
+)
+
+// Actions on a task checked by authorize
+const (
+	// actionView reads a task, its activity and its dependencies. Allowed to
+	// the task's assignee.
+	actionView = "view"
+	// actionEdit changes a task's fields and status. Allowed to the task's
+	// assignee.
+	actionEdit = "edit"
+	// actionManage deletes, archives, pins, assigns or shares a task
+	actionManage = "manage"
+)
+
+// collaboratorActions lists the actions each collaborator role allows. Any
+// other action is left to the task's owner.
+var collaboratorActions = map[models.CollaboratorRole][]string{
+	models.CollaboratorViewer: {actionView},
+	models.CollaboratorEditor: {actionView, actionEdit},
+}
+
+// assigneeActions lists the actions the user a task is assigned to may
+// perform, the same as an editor's
+var assigneeActions = collaboratorActions[models.CollaboratorEditor]
+
+// authorize returns the task if the user may perform action on it: its owner
+// may do anything, its assignee view and edit it, and users it is shared
+// with what their role allows
+func (s *TaskService) authorize(ctx context.Context, taskID, userID uint, action string) (*models.Task, error) {
+	var task models.Task
+	if err := s.db.WithContext(ctx).First(&task, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("failed to retrieve task: %w", err)
+	}
+
+	if err := s.checkAccess(ctx, taskID, task.UserID, task.AssigneeID, userID, action); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// checkAccess is authorize for a task already loaded. Users the task isn't
+// shared with or assigned to get ErrTaskNotFound, so that other users' tasks
+// aren't revealed; assignees and collaborators who may not perform the
+// action get ErrForbidden.
+func (s *TaskService) checkAccess(ctx context.Context, taskID, ownerID uint, assigneeID *uint, userID uint, action string) error {
+	if ownerID == userID {
+		return nil
+	}
+	assigned := assigneeID != nil && *assigneeID == userID
+	if assigned && slices.Contains(assigneeActions, action) {
+		return nil
+	}
+
+	var collaborator models.TaskCollaborator
+	if err := s.db.WithContext(ctx).Where("task_id = ? AND user_id = ?", taskID, userID).First(&collaborator).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if assigned {
+				return apperrors.ErrForbidden.WithMessage("The task's assignee cannot do this")
+			}
+			return ErrTaskNotFound
+		}
+		return fmt.Errorf("failed to retrieve collaborator: %w", err)
+	}
+
+	if !slices.Contains(collaboratorActions[collaborator.Role], action) {
+		return apperrors.ErrForbidden.WithMessage(fmt.Sprintf("Your %s role on this task does not allow this", collaborator.Role))
+	}
+	return nil
+}
+
+// sharedTaskIDs returns a subquery selecting the IDs of the tasks shared with
+// the user
+func sharedTaskIDs(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Model(&models.TaskCollaborator{}).Select("task_id").Where("user_id = ?", userID)
+}
+
+// ShareTask shares a task owned by ownerID with the user with the given
+// email, or changes the role of a user it is already shared with
+func (s *TaskService) ShareTask(ctx context.Context, taskID, ownerID uint, email string, role models.CollaboratorRole) (*models.TaskCollaborator, error) {
+	if !role.IsValid() {
+		return nil, apperrors.ErrValidation.WithFields(map[string]string{"role": "must be one of viewer, editor"})
+	}
+
+	// Read back from the primary what is written below
+	primary := s.WithPrimary()
+	task, err := primary.authorize(ctx, taskID, ownerID, actionManage)
+	if err != nil {
+		return nil, err
+	}
+
+	db := primary.db.WithContext(ctx)
+	var user models.User
+	if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if user.ID == task.UserID {
+		return nil, apperrors.ErrBadRequest.WithMessage("Cannot share a task with its owner")
+	}
+
+	collaborator := models.TaskCollaborator{
+		TaskID: task.ID,
+		UserID: user.ID,
+		Role:   role,
+	}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(&collaborator).Error; err != nil {
+		return nil, fmt.Errorf("failed to share task: %w", err)
+	}
+
+	// Read the row back: on a role change the insert didn't happen
+	if err := db.Preload("User").
+		Where("task_id = ? AND user_id = ?", task.ID, user.ID).
+		First(&collaborator).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve collaborator: %w", err)
+	}
+	return &collaborator, nil
+}
+
+// GetCollaborators returns the users a task is shared with, in the order it
+// was shared with them
+func (s *TaskService) GetCollaborators(ctx context.Context, taskID, userID uint) ([]models.TaskCollaborator, error) {
+	if _, err := s.authorize(ctx, taskID, userID, actionView); err != nil {
+		return nil, err
+	}
+
+	collaborators := []models.TaskCollaborator{}
+	if err := s.db.WithContext(ctx).Preload("User").
+		Where("task_id = ?", taskID).
+		Order("id asc").
+		Find(&collaborators).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve collaborators: %w", err)
+	}
+	return collaborators, nil
+}
+
+// UnshareTask stops sharing a task with collaboratorID. The owner can remove
+// any collaborator; collaborators can only remove themselves.
+func (s *TaskService) UnshareTask(ctx context.Context, taskID, userID, collaboratorID uint) error {
+	action := actionManage
+	if userID == collaboratorID {
+		action = actionView
+	}
+	if _, err := s.WithPrimary().authorize(ctx, taskID, userID, action); err != nil {
+		return err
+	}
+
+	result := s.db.WithContext(ctx).
+		Where("task_id = ? AND user_id = ?", taskID, collaboratorID).
+		Delete(&models.TaskCollaborator{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to unshare task: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrCollaboratorNotFound
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"task-manager/internal/models"
+)
+
+func TestAssigneeAccess(t *testing.T) {
+	db := newTestDB(t)
+	owner := seedUser(t, db, "owner")
+	assignee := seedUser(t, db, "assignee")
+	viewer := seedUser(t, db, "viewer")
+	other := seedUser(t, db, "other")
+	ctx := context.Background()
+	service := NewTaskService()
+
+	assigned := createTask(t, owner.ID, "Assigned")
+	shared := createTask(t, owner.ID, "Shared")
+	private := createTask(t, owner.ID, "Private")
+	if _, err := service.AssignTask(ctx, assigned.ID, owner.ID, assignee.Email); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.ShareTask(ctx, shared.ID, owner.ID, viewer.Email, models.CollaboratorViewer); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("activity", func(t *testing.T) {
+		activity, err := service.GetTaskActivity(ctx, assigned.ID, assignee.ID, 1, 10)
+		if err != nil {
+			t.Fatalf("assignee can't read the activity: %v", err)
+		}
+		if activity.TotalItems == 0 {
+			t.Error("the assignment wasn't recorded")
+		}
+		if _, err := service.GetTaskActivity(ctx, assigned.ID, other.ID, 1, 10); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("another user got %v, want ErrTaskNotFound", err)
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		ids := []uint{assigned.ID, shared.ID, private.ID}
+		for _, tc := range []struct {
+			name   string
+			userID uint
+			want   []uint
+		}{
+			{"owner", owner.ID, ids},
+			{"assignee", assignee.ID, []uint{assigned.ID}},
+			{"collaborator", viewer.ID, []uint{shared.ID}},
+			{"other user", other.ID, nil},
+		} {
+			tasks, err := service.GetTasksByIDs(ctx, ids, tc.userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []uint
+			for _, task := range tasks {
+				got = append(got, task.ID)
+			}
+			if len(got) != len(tc.want) {
+				t.Errorf("%s got tasks %v, want %v", tc.name, got, tc.want)
+				continue
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("%s got tasks %v, want %v", tc.name, got, tc.want)
+					break
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"task-manager/internal/models"
+)
+
+// UserExport holds everything stored about a user, for data portability
+// requests. Tasks other users own are left out, even if shared with or
+// assigned to the user.
+type UserExport struct {
+	ExportedAt time.Time
+	User       *models.User
+	Tasks      []models.Task
+	// DeletedTasks are the user's soft-deleted tasks not purged yet
+	DeletedTasks []models.Task
+	// Activity is the change history of the user's tasks, deleted or not
+	Activity []models.TaskActivity
+	// Dependencies links the user's tasks to the tasks they depend on
+	Dependencies []models.TaskDependency
+	// Collaborators lists whom the user's tasks are shared with
+	Collaborators []models.TaskCollaborator
+	APIKeys       []models.APIKey
+	Sessions      []models.Session
+}
+
+// ExportData collects the user's data, read in one transaction so that the
+// parts are consistent with each other. Password and API key hashes are not
+// part of the models' JSON and so are never exported.
+func (s *UserService) ExportData(ctx context.Context, userID uint) (*UserExport, error) {
+	export := UserExport{
+		ExportedAt:    time.Now(),
+		Tasks:         []models.Task{},
+		DeletedTasks:  []models.Task{},
+		Activity:      []models.TaskActivity{},
+		Dependencies:  []models.TaskDependency{},
+		Collaborators: []models.TaskCollaborator{},
+		APIKeys:       []models.APIKey{},
+		Sessions:      []models.Session{},
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if export.User, err = s.WithTx(tx).GetUserByID(ctx, userID); err != nil {
+			return err
+		}
+
+		owned := tx.Unscoped().Model(&models.Task{}).Select("id").Where("user_id = ?", userID)
+		queries := []struct {
+			name  string
+			query *gorm.DB
+			dest  interface{}
+		}{
+			{"tasks", tx.Where("user_id = ?", userID).Order("id asc"), &export.Tasks},
+			{"deleted tasks", tx.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).Order("id asc"), &export.DeletedTasks},
+			{"task activity", tx.Where("task_id IN (?)", owned).Order("id asc"), &export.Activity},
+			{"task dependencies", tx.Where("task_id IN (?)", owned).Order("id asc"), &export.Dependencies},
+			{"task collaborators", tx.Where("task_id IN (?)", owned).Order("id asc"), &export.Collaborators},
+			{"API keys", tx.Where("user_id = ?", userID).Order("id asc"), &export.APIKeys},
+			{"sessions", tx.Where("user_id = ?", userID).Order("id asc"), &export.Sessions},
+		}
+		for _, q := range queries {
+			if err := q.query.Find(q.dest).Error; err != nil {
+				return fmt.Errorf("failed to export %s: %w", q.name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &export, nil
+}
@@ -1,12 +1,18 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
-	"golang.org/x/crypto/bcrypt"
 
+	"task-manager/config"
+	"task-manager/internal/apperrors"
 	"task-manager/internal/models"
 	"task-manager/pkg/database"
 	"task-manager/pkg/utils"
@@ -17,18 +23,55 @@ type UserRegisterRequest struct {
 	Username string
 	Email    string
 	Password string
+	// Client is recorded in the session started for the new user
+	Client ClientInfo
+}
+
+// UserCreateRequest defines the data an admin gives to create a user
+type UserCreateRequest struct {
+	Username string
+	Email    string
+	Password string
+	Role     models.Role
 }
 
 // UserLoginRequest defines the data needed to login a user
 type UserLoginRequest struct {
 	Email    string
 	Password string
+	// RememberMe issues a token lasting JWT_REMEMBER_ME_EXPIRES_IN instead
+	// of JWT_EXPIRES_IN
+	RememberMe bool
+	// Client is recorded in the session the login starts
+	Client ClientInfo
 }
 
 // AuthResponse represents the authentication response with token and user details
 type AuthResponse struct {
-	Token string
-	User  *models.User
+	Token     string
+	ExpiresAt time.Time
+	User      *models.User
+}
+
+// TokenIntrospection describes a JWT token. Claims are only filled in when
+// the token's signature is valid.
+type TokenIntrospection struct {
+	// Active reports whether the token would be accepted right now
+	Active bool
+	// Reason is the error code explaining why the token isn't active
+	Reason    string
+	UserID    uint
+	IssuedAt  *time.Time
+	ExpiresAt *time.Time
+}
+
+// PaginatedUsersResponse represents a paginated list of users
+type PaginatedUsersResponse struct {
+	Users       []models.User
+	CurrentPage int
+	PageSize    int
+	TotalItems  int64
+	TotalPages  int64
 }
 
 // UserService provides methods for user-related operations
@@ -43,21 +86,93 @@ func NewUserService() *UserService {
 	}
 }
 
-// Register creates a new user account
-func (s *UserService) Register(req UserRegisterRequest) (*AuthResponse, error) {
-	// Check if username already exists
+// WithTx returns a copy of the service that runs its queries on tx,
+// allowing several operations to share one transaction
+func (s *UserService) WithTx(tx *gorm.DB) *UserService {
+	return &UserService{
+		db: tx,
+	}
+}
+
+// Register creates a new user account and logs the user in. The account is
+// only kept if its session can be started too, so that a failed
+// registration can be retried.
+func (s *UserService) Register(ctx context.Context, req UserRegisterRequest) (*AuthResponse, error) {
+	var resp *AuthResponse
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user, err := s.WithTx(tx).CreateUser(ctx, UserCreateRequest{
+			Username: req.Username,
+			Email:    req.Email,
+			Password: req.Password,
+			Role:     models.RoleUser,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Log the new user in
+		token, expiresAt, err := NewSessionService().WithTx(tx).StartSession(ctx, user.ID, req.Client, config.GetConfig().JWT.ExpiresIn)
+		if err != nil {
+			return err
+		}
+
+		resp = &AuthResponse{
+			Token:     token,
+			ExpiresAt: expiresAt,
+			User:      user,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CreateUser creates a user account without logging it in
+func (s *UserService) CreateUser(ctx context.Context, req UserCreateRequest) (*models.User, error) {
+	if err := checkPasswordStrength(req.Password); err != nil {
+		return nil, err
+	}
+
+	// Check uniqueness and create the user atomically
+	var user *models.User
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = s.WithTx(tx).createUser(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// createUser checks that the username and email are free and inserts the user.
+// Deleted accounts keep their username and email: the unique indexes cover
+// soft-deleted rows too, so neither can be reused.
+func (s *UserService) createUser(ctx context.Context, req UserCreateRequest) (*models.User, error) {
+	db := s.db.WithContext(ctx)
+
+	// Check if username already exists, including on deleted accounts
 	var existingUser models.User
-	result := s.db.Where("username = ?", req.Username).First(&existingUser)
+	result := db.Unscoped().Where("username = ?", req.Username).First(&existingUser)
 	if result.Error == nil {
-		return nil, errors.New("username already exists")
+		if existingUser.DeletedAt.Valid {
+			return nil, ErrUsernameTaken.WithMessage("Username belonged to a deleted account and cannot be reused")
+		}
+		return nil, ErrUsernameTaken
 	} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("database error while checking username: %w", result.Error)
 	}
 
-	// Check if email already exists
-	result = s.db.Where("email = ?", req.Email).First(&existingUser)
+	// Check if email already exists, including on deleted accounts
+	result = db.Unscoped().Where("email = ?", req.Email).First(&existingUser)
 	if result.Error == nil {
-		return nil, errors.New("email already exists")
+		if existingUser.DeletedAt.Valid {
+			return nil, ErrEmailTaken.WithMessage("Email belonged to a deleted account and cannot be reused")
+		}
+		return nil, ErrEmailTaken
 	} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("database error while checking email: %w", result.Error)
 	}
@@ -67,74 +182,195 @@ func (s *UserService) Register(req UserRegisterRequest) (*AuthResponse, error) {
 		Username: req.Username,
 		Email:    req.Email,
 		Password: req.Password, // Will be hashed by BeforeSave hook
+		Role:     req.Role,
 	}
 
-	// Save user to database
-	if err := s.db.Create(&user).Error; err != nil {
+	// Save user to database. A concurrent registration can still slip past the
+	// checks above, in which case the unique index rejects the insert.
+	if err := db.Create(&user).Error; err != nil {
+		if isDuplicateKeyError(err) {
+			if strings.Contains(err.Error(), "email") {
+				return nil, ErrEmailTaken
+			}
+			return nil, ErrUsernameTaken
+		}
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	// Generate session ID
-	token, err := utils.GenerateToken(user.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate session: %w", err)
-	}
-
-	return &AuthResponse{
-		Token: token,
-		User:  &user,
-	}, nil
+	return &user, nil
 }
 
 // Login authenticates a user and returns a token
-func (s *UserService) Login(req UserLoginRequest) (*AuthResponse, error) {
+func (s *UserService) Login(ctx context.Context, req UserLoginRequest) (*AuthResponse, error) {
+	db := s.db.WithContext(ctx)
+
+	// Refuse to check the password while the account is locked out
+	attempts := NewLoginAttemptService().WithTx(db)
+	now := time.Now()
+	if err := attempts.CheckLocked(req.Email, now); err != nil {
+		return nil, err
+	}
+
 	// Find user by email
 	var user models.User
-	result := s.db.Where("email = ?", req.Email).First(&user)
+	result := db.Where("email = ?", req.Email).First(&user)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, errors.New("invalid email or password")
+			// Count failures for unknown emails too so locking doesn't
+			// reveal which accounts exist
+			return nil, s.loginFailed(attempts, req.Email, now)
 		}
 		return nil, fmt.Errorf("database error: %w", result.Error)
 	}
 
 	// Verify password
 	if err := user.CheckPassword(req.Password); err != nil {
-		return nil, errors.New("invalid email or password")
+		return nil, s.loginFailed(attempts, req.Email, now)
+	}
+
+	if err := attempts.Reset(req.Email); err != nil {
+		log.Printf("Failed to reset login attempts for user %d: %v", user.ID, err)
+	}
+
+	// Upgrade hashes made with an older, lower cost now that we know the
+	// plaintext password
+	if user.NeedsRehash() {
+		s.rehashPassword(ctx, &user, req.Password)
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateToken(user.ID)
+	// Generate JWT token, longer-lived if the user asked to be remembered
+	jwtConfig := config.GetConfig().JWT
+	expiresIn := jwtConfig.ExpiresIn
+	if req.RememberMe {
+		expiresIn = jwtConfig.RememberMeExpiresIn
+	}
+	token, expiresAt, err := NewSessionService().WithTx(db).StartSession(ctx, user.ID, req.Client, expiresIn)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate JWT token: %w", err)
+		return nil, err
 	}
 
 	return &AuthResponse{
-		Token: token,
-		User:  &user,
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      &user,
 	}, nil
 }
 
 // GetUserByID retrieves a user by their ID
-func (s *UserService) GetUserByID(id uint) (*models.User, error) {
+func (s *UserService) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	result := s.db.First(&user, id)
+	result := s.db.WithContext(ctx).First(&user, id)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, apperrors.ErrUserNotFound
 		}
 		return nil, fmt.Errorf("database error: %w", result.Error)
 	}
 	return &user, nil
 }
 
+// ClearLoginLockout lifts any lockout of the user's account and forgets their
+// failed logins, reporting whether the account was locked
+func (s *UserService) ClearLoginLockout(ctx context.Context, userID uint) (bool, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	attempts := NewLoginAttemptService().WithTx(s.db.WithContext(ctx))
+	locked, err := attempts.IsLocked(user.Email, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if err := attempts.Reset(user.Email); err != nil {
+		return false, err
+	}
+	return locked, nil
+}
+
+// IntrospectToken decodes token and reports whether it is still accepted.
+// Besides being genuine and unexpired, its user must still exist and, for
+// tokens issued at login, its session must not have been revoked.
+func (s *UserService) IntrospectToken(ctx context.Context, token string) (*TokenIntrospection, error) {
+	claims, err := utils.ParseToken(token)
+	if claims == nil {
+		return &TokenIntrospection{Reason: apperrors.ErrInvalidToken.Code}, nil
+	}
+
+	result := &TokenIntrospection{UserID: claims.UserID}
+	if claims.IssuedAt != nil {
+		result.IssuedAt = &claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		result.ExpiresAt = &claims.ExpiresAt.Time
+	}
+
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		result.Reason = apperrors.ErrTokenExpired.Code
+		return result, nil
+	case err != nil:
+		result.Reason = apperrors.ErrInvalidToken.Code
+		return result, nil
+	}
+
+	if _, err := s.GetUserByID(ctx, claims.UserID); err != nil {
+		if errors.Is(err, apperrors.ErrUserNotFound) {
+			result.Reason = apperrors.ErrUserNotFound.Code
+			return result, nil
+		}
+		return nil, err
+	}
+	if claims.SessionID != 0 {
+		if err := NewSessionService().WithTx(s.db).Authenticate(ctx, claims.SessionID, claims.UserID); err != nil {
+			if errors.Is(err, apperrors.ErrSessionRevoked) {
+				result.Reason = apperrors.ErrSessionRevoked.Code
+				return result, nil
+			}
+			return nil, err
+		}
+	}
+
+	result.Active = true
+	return result, nil
+}
+
+// ListUsers returns a page of all users, oldest first
+func (s *UserService) ListUsers(ctx context.Context, page, pageSize int) (*PaginatedUsersResponse, error) {
+	db := s.db.WithContext(ctx)
+
+	page, pageSize = normalizePagination(page, pageSize)
+
+	var totalItems int64
+	if err := db.Model(&models.User{}).Count(&totalItems).Error; err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+	page, totalPages := clampPage(page, pageSize, totalItems)
+
+	var users []models.User
+	if err := db.Order("id asc").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve users: %w", err)
+	}
+
+	return &PaginatedUsersResponse{
+		Users:       users,
+		CurrentPage: page,
+		PageSize:    pageSize,
+		TotalItems:  totalItems,
+		TotalPages:  totalPages,
+	}, nil
+}
+
 // GetUserByEmail retrieves a user by their email
-func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	result := s.db.Where("email = ?", email).First(&user)
+	result := s.db.WithContext(ctx).Where("email = ?", email).First(&user)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, apperrors.ErrUserNotFound
 		}
 		return nil, fmt.Errorf("database error: %w", result.Error)
 	}
@@ -142,12 +378,12 @@ func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
 }
 
 // GetUserByUsername retrieves a user by their username
-func (s *UserService) GetUserByUsername(username string) (*models.User, error) {
+func (s *UserService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	result := s.db.Where("username = ?", username).First(&user)
+	result := s.db.WithContext(ctx).Where("username = ?", username).First(&user)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, apperrors.ErrUserNotFound
 		}
 		return nil, fmt.Errorf("database error: %w", result.Error)
 	}
@@ -155,31 +391,77 @@ func (s *UserService) GetUserByUsername(username string) (*models.User, error) {
 }
 
 // UpdateUser updates user information
-func (s *UserService) UpdateUser(userID uint, updates map[string]interface{}) (*models.User, error) {
+func (s *UserService) UpdateUser(ctx context.Context, userID uint, updates map[string]interface{}) (*models.User, error) {
+	db := s.db.WithContext(ctx)
+
 	// Get the user
-	user, err := s.GetUserByID(userID)
+	user, err := s.GetUserByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	// If password is being updated, hash it
+	// If password is being updated, check it against the policy and hash it
 	if password, ok := updates["password"].(string); ok {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err := checkPasswordStrength(password); err != nil {
+			return nil, err
+		}
+		hashedPassword, err := models.HashPassword(password)
 		if err != nil {
 			return nil, fmt.Errorf("failed to hash password: %w", err)
 		}
-		updates["password"] = string(hashedPassword)
+		updates["password"] = hashedPassword
 	}
 
 	// Apply updates
-	if err := s.db.Model(user).Updates(updates).Error; err != nil {
+	if err := db.Model(user).Updates(updates).Error; err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
 	// Refresh user data
-	if err := s.db.First(user, userID).Error; err != nil {
+	if err := db.First(user, userID).Error; err != nil {
 		return nil, fmt.Errorf("failed to retrieve updated user: %w", err)
 	}
 
 	return user, nil
-}
\ No newline at end of file
+}
+
+// loginFailed records a failed login and returns the error to report
+func (s *UserService) loginFailed(attempts *LoginAttemptService, email string, now time.Time) error {
+	if err := attempts.RecordFailure(email, now); err != nil {
+		return err
+	}
+	return apperrors.ErrInvalidCredentials
+}
+
+// checkPasswordStrength reports a password breaking the password policy as a
+// validation error on the password field
+func checkPasswordStrength(password string) error {
+	if err := utils.ValidatePasswordStrength(password); err != nil {
+		return apperrors.ErrValidation.WithFields(map[string]string{"password": err.Error()})
+	}
+	return nil
+}
+
+// rehashPassword stores a new hash of password using the configured cost.
+// Failures are logged only, since the login itself has succeeded.
+func (s *UserService) rehashPassword(ctx context.Context, user *models.User, password string) {
+	hashedPassword, err := models.HashPassword(password)
+	if err != nil {
+		log.Printf("Failed to rehash password for user %d: %v", user.ID, err)
+		return
+	}
+
+	// UpdateColumn skips the BeforeSave hook, which would hash the hash again
+	if err := s.db.WithContext(ctx).Model(user).UpdateColumn("password", hashedPassword).Error; err != nil {
+		log.Printf("Failed to store rehashed password for user %d: %v", user.ID, err)
+		return
+	}
+	user.Password = hashedPassword
+}
+
+// isDuplicateKeyError reports whether err is a unique constraint violation
+// raised by MySQL or SQLite
+func isDuplicateKeyError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "Duplicate entry") || strings.Contains(msg, "UNIQUE constraint failed")
+}
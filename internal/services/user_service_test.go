@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"task-manager/internal/apperrors"
+	"task-manager/internal/models"
+)
+
+func TestUserServiceRegisterAndLogin(t *testing.T) {
+	newTestDB(t)
+	ctx := context.Background()
+	service := NewUserService()
+
+	registered, err := service.Register(ctx, UserRegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registered.Token == "" || registered.User.Username != "alice" {
+		t.Errorf("got token %q for user %q, want a token for alice", registered.Token, registered.User.Username)
+	}
+
+	for _, tc := range []struct {
+		name string
+		req  UserRegisterRequest
+		want error
+	}{
+		{"username taken", UserRegisterRequest{Username: "alice", Email: "other@example.com", Password: "password123"}, ErrUsernameTaken},
+		{"email taken", UserRegisterRequest{Username: "other", Email: "alice@example.com", Password: "password123"}, ErrEmailTaken},
+	} {
+		if _, err := service.Register(ctx, tc.req); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	loggedIn, err := service.Login(ctx, UserLoginRequest{Email: "alice@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loggedIn.User.ID != registered.User.ID {
+		t.Errorf("logged in as user %d, want %d", loggedIn.User.ID, registered.User.ID)
+	}
+
+	if _, err := service.Login(ctx, UserLoginRequest{Email: "alice@example.com", Password: "wrong"}); !errors.Is(err, apperrors.ErrInvalidCredentials) {
+		t.Errorf("wrong password: got %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestRegisterRollsBackWithoutSession(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	// Without the sessions table the new user can't be logged in
+	if err := db.Migrator().DropTable(&models.Session{}); err != nil {
+		t.Fatal(err)
+	}
+	req := UserRegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"}
+	if _, err := NewUserService().Register(ctx, req); err == nil {
+		t.Fatal("registered without a session")
+	}
+
+	var count int64
+	if err := db.Model(&models.User{}).Where("email = ?", req.Email).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Error("the user was kept after their session failed")
+	}
+}
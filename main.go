@@ -1,63 +1,53 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"os"
-
-	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-
-	"task-manager/internal/middlewares"
-	"task-manager/internal/models"
-	"task-manager/internal/routes"
-	"task-manager/pkg/database"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Embedded zone database so APP_TIMEZONE works in minimal images
+
+	"task-manager/config"
+	"task-manager/internal/server"
+	"task-manager/pkg/version"
 )
 
+//	@title						Task Manager API
+//	@version					1.0
+//	@description				RESTful API for user authentication and task management.
+//	@BasePath					/api/v1
+//	@securityDefinitions.apikey	BearerAuth
+//	@in							header
+//	@name						Authorization
+//	@description				JWT token in the format "Bearer {token}"
+//	@securityDefinitions.apikey	ApiKeyAuth
+//	@in							header
+//	@name						X-API-Key
+//	@description				API key created via /me/api-keys
 func main() {
-	// Load environment variables from .env file
-	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: .env file not found or could not be loaded: %v", err)
-	}
+	version.SetStartTime(time.Now())
 
-	// Set Gin mode based on environment
-	if os.Getenv("APP_ENV") == "production" {
-		gin.SetMode(gin.ReleaseMode)
-	} else {
-		gin.SetMode(gin.DebugMode)
-	}
+	// Load environment variables from .env and .env.{APP_ENV}
+	config.LoadEnvFiles()
 
-	// Initialize database connection
-	db, err := database.InitDB()
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	// Load and validate configuration, refusing to start with unsafe settings
+	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("%v", err)
 	}
 
-	// Setup database models and migrations
-	if err := models.SetupModels(db); err != nil {
-		log.Fatalf("Failed to setup database models: %v", err)
+	srv, err := server.New(cfg)
+	if err != nil {
+		log.Fatalf("%v", err)
 	}
 
-	// Initialize Gin router
-	router := gin.New()
-
-	// Apply middlewares
-	router.Use(gin.Recovery())
-	router.Use(middlewares.LoggerMiddleware())
-
-	// Setup routes using the routes package
-	routes.SetupRoutes(router)
-
-	// Get port from environment variable, default to 8080
-	port := os.Getenv("APP_PORT")
-	if port == "" {
-		port = "8080"
-	}
+	// Stop gracefully on Ctrl+C or when the platform asks us to
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Start the server
-	serverAddr := fmt.Sprintf(":%s", port)
-	log.Printf("Server starting on %s", serverAddr)
-	if err := router.Run(serverAddr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	if err := srv.Run(ctx); err != nil {
+		log.Fatalf("%v", err)
 	}
-}
\ No newline at end of file
+}
@@ -6,24 +6,51 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
+
+	"task-manager/config"
+)
+
+// Supported database drivers
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
 )
 
+// memoryDBName is the DB_NAME value that selects an in-memory SQLite database
+const memoryDBName = ":memory:"
+
 var (
-	DB *gorm.DB
+	// dbMu guards defaultDB, the connection shared by the application
+	dbMu      sync.RWMutex
+	defaultDB *gorm.DB
+
+	// initMu serializes InitDB, InitTestDB and Reset so that concurrent
+	// callers open a single connection
+	initMu sync.Mutex
+
 	// ErrMaxRetriesReached is returned when the database connection fails after max retries
 	ErrMaxRetriesReached = errors.New("max connection retries reached")
+
+	// testDBCounter gives each test database a unique in-memory name
+	testDBCounter uint64
 )
 
 // DBConfig holds database connection configuration
 type DBConfig struct {
+	Driver         string
 	Host           string
 	Port           string
 	User           string
@@ -37,44 +64,46 @@ type DBConfig struct {
 	ConnMaxLifetime time.Duration
 	RetryAttempts  int
 	RetryDelay     time.Duration
+	SlowQueryThreshold time.Duration
 	AllowNativeAuth bool
 	UseSocket      bool
+	ReplicaHosts   []string
 }
 
-// LoadDBConfig loads database configuration from environment variables
+// LoadDBConfig loads database configuration. Connection settings come from
+// the application configuration; pool and retry tuning from environment variables.
 func LoadDBConfig() DBConfig {
-	// Get database connection parameters from environment variables with defaults
+	dbConfig := config.GetConfig().Database
+
+	// Get pool and retry parameters from environment variables with defaults
 	maxOpenConns, _ := strconv.Atoi(getEnvOrDefault("DB_MAX_OPEN_CONNS", "100"))
 	maxIdleConns, _ := strconv.Atoi(getEnvOrDefault("DB_MAX_IDLE_CONNS", "10"))
 	connMaxLifetime, _ := time.ParseDuration(getEnvOrDefault("DB_CONN_MAX_LIFETIME", "1h"))
 	retryAttempts, _ := strconv.Atoi(getEnvOrDefault("DB_RETRY_ATTEMPTS", "3"))
 	retryDelay, _ := time.ParseDuration(getEnvOrDefault("DB_RETRY_DELAY", "2s"))
+	slowQueryThreshold, _ := time.ParseDuration(getEnvOrDefault("DB_SLOW_QUERY_THRESHOLD", "200ms"))
 	allowNativeAuth := strings.ToLower(getEnvOrDefault("DB_ALLOW_NATIVE_AUTH", "true")) == "true"
 	useSocket := strings.ToLower(getEnvOrDefault("DB_USE_SOCKET", "false")) == "true"
 
-	// Handle parseTime parameter correctly
-	parseTime := true
-	dbParseTimeValue := strings.ToLower(getEnvOrDefault("DB_PARSE_TIME", "true"))
-	if dbParseTimeValue == "false" || dbParseTimeValue == "0" {
-		parseTime = false
-	}
-
 	return DBConfig{
-		Host:           getEnvOrDefault("DB_HOST", "localhost"),
-		Port:           getEnvOrDefault("DB_PORT", "3306"),
-		User:           getEnvOrDefault("DB_USER", "root"),
-		Password:       getEnvOrDefault("DB_PASSWORD", "vQFmcfVM"),
-		Name:           getEnvOrDefault("DB_NAME", "task_manager"),
-		Charset:        getEnvOrDefault("DB_CHARSET", "utf8mb4"),
-		ParseTime:      parseTime,
-		Loc:            getEnvOrDefault("DB_LOC", "Local"),
+		Driver:         dbConfig.Driver,
+		Host:           dbConfig.Host,
+		Port:           dbConfig.Port,
+		User:           dbConfig.User,
+		Password:       dbConfig.Password,
+		Name:           dbConfig.Name,
+		Charset:        dbConfig.Charset,
+		ParseTime:      dbConfig.ParseTime,
+		Loc:            dbConfig.Loc,
 		MaxOpenConns:   maxOpenConns,
 		MaxIdleConns:   maxIdleConns,
 		ConnMaxLifetime: connMaxLifetime,
 		RetryAttempts:  retryAttempts,
 		RetryDelay:     retryDelay,
+		SlowQueryThreshold: slowQueryThreshold,
 		AllowNativeAuth: allowNativeAuth,
 		UseSocket:      useSocket,
+		ReplicaHosts:   dbConfig.ReplicaHosts,
 	}
 }
 
@@ -111,24 +140,121 @@ func (c DBConfig) BuildDSN() string {
 	return dsn + "&" + strings.Join(params, "&")
 }
 
-// InitDB initializes the database connection using environment variables
+// ReplicaConfigs returns a configuration for each read replica, sharing the
+// primary's credentials and options. Hosts without a port use the primary's port.
+func (c DBConfig) ReplicaConfigs() []DBConfig {
+	replicas := make([]DBConfig, 0, len(c.ReplicaHosts))
+	for _, hostPort := range c.ReplicaHosts {
+		replica := c
+		replica.UseSocket = false
+		replica.Host = hostPort
+		if host, port, err := net.SplitHostPort(hostPort); err == nil {
+			replica.Host = host
+			replica.Port = port
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas
+}
+
+// BuildSQLiteDSN builds the SQLite connection string. DB_NAME is used as the
+// database file path, or ":memory:" for a shared in-memory database.
+func (c DBConfig) BuildSQLiteDSN() string {
+	if c.Name == memoryDBName {
+		return "file::memory:?cache=shared"
+	}
+	return c.Name + "?_busy_timeout=5000"
+}
+
+// InitDB initializes the database connection using environment variables.
+// Once a connection is open, later calls return it until Reset is called.
 func InitDB() (*gorm.DB, error) {
+	initMu.Lock()
+	defer initMu.Unlock()
+
+	if db := GetDB(); db != nil {
+		return db, nil
+	}
+
 	config := LoadDBConfig()
 
-	// Set up GORM logger configuration based on environment
-	logLevel := logger.Silent
+	// Set up GORM logger configuration based on environment. Outside of
+	// development only slow queries and errors are logged.
+	logLevel := logger.Warn
 	if strings.ToLower(getEnvOrDefault("APP_ENV", "production")) == "development" {
 		logLevel = logger.Info
 	} else if strings.ToLower(getEnvOrDefault("LOG_LEVEL", "")) == "debug" {
 		logLevel = logger.Info
 	}
 
-	// Configure GORM with logger settings
+	// Configure GORM to log through the application log; queries slower
+	// than the threshold are logged as warnings (0 disables slow query logging)
 	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+		Logger: newGormLogger(logLevel, config.SlowQueryThreshold),
 	}
 
-	// Initialize database connection with retry mechanism
+	var (
+		db  *gorm.DB
+		err error
+	)
+
+	switch config.Driver {
+	case DriverMySQL:
+		db, err = openMySQL(config, gormConfig)
+	case DriverSQLite:
+		log.Printf("Opening SQLite database %s...", config.Name)
+		db, err = gorm.Open(sqlite.Open(config.BuildSQLiteDSN()), gormConfig)
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", config.Driver)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := registerQueryCounter(db); err != nil {
+		return nil, err
+	}
+
+	// Route reads to replicas when configured; writes and transactions stay on the primary
+	if len(config.ReplicaHosts) > 0 {
+		if err := registerReplicas(db, config); err != nil {
+			return nil, err
+		}
+	}
+
+	// Configure connection pool settings
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %v", err)
+	}
+
+	// Set connection pool parameters
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+
+	// An in-memory SQLite database only lives as long as its connections,
+	// so never recycle them
+	if config.Driver == DriverSQLite && config.Name == memoryDBName {
+		sqlDB.SetConnMaxLifetime(0)
+	}
+
+	// Print diagnostic information
+	if err := printDatabaseInfo(sqlDB, config.Driver); err != nil {
+		log.Printf("WARNING: Could not retrieve database information: %v", err)
+	}
+
+	// Store the global DB instance
+	setDB(db)
+
+	log.Printf("Successfully connected to database %s", config.Name)
+	return db, nil
+}
+
+// openMySQL opens a MySQL connection with retries, exponential backoff and
+// a socket fallback on the last attempt
+func openMySQL(config DBConfig, gormConfig *gorm.Config) (*gorm.DB, error) {
 	var (
 		db  *gorm.DB
 		err error
@@ -182,31 +308,96 @@ func InitDB() (*gorm.DB, error) {
 		return nil, fmt.Errorf("%w: %v", ErrMaxRetriesReached, err)
 	}
 
-	// Store the global DB instance
-	DB = db
+	return db, nil
+}
 
-	// Configure connection pool settings
-	sqlDB, err := DB.DB()
+// registerReplicas installs the dbresolver plugin so that queries are served
+// by the read replicas while Create/Save/Update/Delete go to the primary
+func registerReplicas(db *gorm.DB, config DBConfig) error {
+	if config.Driver != DriverMySQL {
+		log.Printf("WARNING: DB_REPLICA_HOSTS is only supported with the mysql driver, ignoring replicas")
+		return nil
+	}
+
+	replicas := make([]gorm.Dialector, 0, len(config.ReplicaHosts))
+	for _, replica := range config.ReplicaConfigs() {
+		replicas = append(replicas, mysql.Open(replica.BuildDSN()))
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}).
+		SetMaxIdleConns(config.MaxIdleConns).
+		SetMaxOpenConns(config.MaxOpenConns).
+		SetConnMaxLifetime(config.ConnMaxLifetime)
+
+	if err := db.Use(resolver); err != nil {
+		return fmt.Errorf("failed to register read replicas: %w", err)
+	}
+
+	log.Printf("Routing reads to %d replica(s): %s", len(replicas), strings.Join(config.ReplicaHosts, ", "))
+	return nil
+}
+
+// InitTestDB opens a fresh, isolated in-memory SQLite database intended for
+// unit tests and installs it as the global DB. Each call returns a new empty
+// database; callers are responsible for running models.SetupModels.
+func InitTestDB() (*gorm.DB, error) {
+	initMu.Lock()
+	defer initMu.Unlock()
+
+	name := fmt.Sprintf("file:testdb_%d?mode=memory&cache=shared", atomic.AddUint64(&testDBCounter, 1))
+	db, err := gorm.Open(sqlite.Open(name), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open test database: %w", err)
+	}
+	if err := registerQueryCounter(db); err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get database connection: %v", err)
 	}
+	sqlDB.SetConnMaxLifetime(0)
 
-	// Set connection pool parameters
-	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
-	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
-	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	setDB(db)
+	return db, nil
+}
 
-	// Print diagnostic information
-	if err := printDatabaseInfo(sqlDB); err != nil {
-		log.Printf("WARNING: Could not retrieve database information: %v", err)
+// Reset closes the global database connection, if any, so that the next
+// InitDB opens a new one. It is meant for tests.
+func Reset() error {
+	initMu.Lock()
+	defer initMu.Unlock()
+
+	db := GetDB()
+	if db == nil {
+		return nil
 	}
+	setDB(nil)
 
-	log.Printf("Successfully connected to database %s", config.Name)
-	return DB, nil
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %v", err)
+	}
+	return sqlDB.Close()
 }
 
 // printDatabaseInfo prints diagnostic information about the database
-func printDatabaseInfo(db *sql.DB) error {
+func printDatabaseInfo(db *sql.DB, driver string) error {
+	if driver == DriverSQLite {
+		var version string
+		if err := db.QueryRow("SELECT sqlite_version()").Scan(&version); err != nil {
+			return err
+		}
+		log.Printf("Connected to SQLite version: %s", version)
+		return db.Ping()
+	}
+
 	var version string
 	err := db.QueryRow("SELECT VERSION()").Scan(&version)
 	if err != nil {
@@ -250,7 +441,69 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return value
 }
 
+// PoolStats represents a snapshot of the connection pool statistics
+type PoolStats struct {
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDuration       string `json:"wait_duration"`
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+}
+
+// Stats returns the current connection pool statistics of the primary database
+func Stats() (*PoolStats, error) {
+	db := GetDB()
+	if db == nil {
+		return nil, errors.New("database not initialized")
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %v", err)
+	}
+
+	stats := sqlDB.Stats()
+	return &PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration.String(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}, nil
+}
+
+// Ping verifies that the primary database is reachable
+func Ping(ctx context.Context) error {
+	db := GetDB()
+	if db == nil {
+		return errors.New("database not initialized")
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %v", err)
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 // GetDB returns the database instance
 func GetDB() *gorm.DB {
-	return DB
+	dbMu.RLock()
+	defer dbMu.RUnlock()
+	return defaultDB
+}
+
+// setDB replaces the global database instance
+func setDB(db *gorm.DB) {
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	defaultDB = db
 }
\ No newline at end of file
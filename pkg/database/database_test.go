@@ -0,0 +1,95 @@
+package database
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	// The configuration is loaded once, so select an in-memory SQLite
+	// database before any test reads it
+	os.Setenv("DB_DRIVER", DriverSQLite)
+	os.Setenv("DB_NAME", memoryDBName)
+	os.Exit(m.Run())
+}
+
+// concurrently runs f in n goroutines at once and waits for them to finish
+func concurrently(n int, f func(i int)) {
+	var start, done sync.WaitGroup
+	start.Add(1)
+	for i := 0; i < n; i++ {
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			start.Wait()
+			f(i)
+		}(i)
+	}
+	start.Done()
+	done.Wait()
+}
+
+func TestInitDBConcurrent(t *testing.T) {
+	t.Cleanup(func() { Reset() })
+
+	dbs := make([]*gorm.DB, 16)
+	errs := make([]error, len(dbs))
+	concurrently(len(dbs), func(i int) {
+		dbs[i], errs[i] = InitDB()
+	})
+
+	for i := range dbs {
+		if errs[i] != nil {
+			t.Fatalf("InitDB failed: %v", errs[i])
+		}
+		if dbs[i] != dbs[0] {
+			t.Fatal("concurrent InitDB calls opened more than one connection")
+		}
+	}
+	if GetDB() != dbs[0] {
+		t.Error("GetDB doesn't return the connection InitDB opened")
+	}
+}
+
+func TestInitTestDBAndResetConcurrent(t *testing.T) {
+	t.Cleanup(func() { Reset() })
+
+	concurrently(24, func(i int) {
+		switch i % 3 {
+		case 0:
+			if _, err := InitTestDB(); err != nil {
+				t.Errorf("InitTestDB failed: %v", err)
+			}
+		case 1:
+			if err := Reset(); err != nil {
+				t.Errorf("Reset failed: %v", err)
+			}
+		default:
+			GetDB()
+		}
+	})
+}
+
+func TestResetClosesConnection(t *testing.T) {
+	db, err := InitTestDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Reset(); err != nil {
+		t.Fatal(err)
+	}
+
+	if GetDB() != nil {
+		t.Error("GetDB still returns a connection after Reset")
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sqlDB.Ping(); err == nil {
+		t.Error("connection still usable after Reset")
+	}
+}
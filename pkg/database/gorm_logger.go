@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+
+	"task-manager/pkg/logging"
+)
+
+// QueryLogData represents structured log data for a database query
+type QueryLogData struct {
+	Timestamp     string        `json:"timestamp"`
+	Query         string        `json:"query"`
+	RowsAffected  int64         `json:"rows_affected"`
+	Duration      time.Duration `json:"duration"`
+	DurationHuman string        `json:"duration_human"`
+	Source        string        `json:"source"`
+	Slow          bool          `json:"slow,omitempty"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// messageLogData represents structured log data for a message from GORM
+type messageLogData struct {
+	Timestamp string `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+// gormLogger is a GORM logger that writes to the application log, in the
+// same format as the request log. GORM's level selects which queries are
+// logged; the application's LOG_LEVEL then applies as for any other line.
+type gormLogger struct {
+	level         logger.LogLevel
+	slowThreshold time.Duration
+}
+
+// newGormLogger returns a GORM logger at level that logs queries slower than
+// slowThreshold as warnings (0 disables slow query logging)
+func newGormLogger(level logger.LogLevel, slowThreshold time.Duration) logger.Interface {
+	return &gormLogger{level: level, slowThreshold: slowThreshold}
+}
+
+// LogMode implements logger.Interface
+func (l *gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+// Info implements logger.Interface
+func (l *gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		logMessage(logging.InfoLevel, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Warn implements logger.Interface
+func (l *gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		logMessage(logging.WarnLevel, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Error implements logger.Interface
+func (l *gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		logMessage(logging.ErrorLevel, fmt.Sprintf(msg, args...))
+	}
+}
+
+// Trace implements logger.Interface. Failed queries are logged as errors,
+// except for records not found, which callers expect; slow queries as
+// warnings, and any other query only at GORM's Info level.
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	slow := l.slowThreshold > 0 && elapsed > l.slowThreshold
+
+	var level logging.Level
+	switch {
+	case failed && l.level >= logger.Error:
+		level = logging.ErrorLevel
+	case slow && l.level >= logger.Warn:
+		level = logging.WarnLevel
+	case l.level >= logger.Info:
+		level = logging.InfoLevel
+	default:
+		return
+	}
+	if !logging.Enabled(level) {
+		return
+	}
+
+	query, rows := fc()
+	logData := QueryLogData{
+		Timestamp:     time.Now().Format(time.RFC3339),
+		Query:         query,
+		RowsAffected:  rows,
+		Duration:      elapsed,
+		DurationHuman: elapsed.String(),
+		Source:        utils.FileWithLineNum(),
+		Slow:          slow,
+	}
+	if failed {
+		logData.Error = err.Error()
+	}
+	logJSON(level, logData)
+}
+
+// logMessage logs a message from GORM, e.g. from the migrator
+func logMessage(level logging.Level, msg string) {
+	if logging.Enabled(level) {
+		logJSON(level, messageLogData{Timestamp: time.Now().Format(time.RFC3339), Message: msg})
+	}
+}
+
+// logJSON logs v encoded as JSON, or in Go syntax if it can't be encoded
+func logJSON(level logging.Level, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		logging.Log(level, fmt.Sprintf("%+v", v))
+		return
+	}
+	logging.Log(level, string(data))
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// queryCounterKey is the context key of the QueryCounter of a context
+type queryCounterKey struct{}
+
+// QueryCounter counts the SQL statements GORM runs with a context returned
+// by WithQueryCounter. Tests use it to check that an endpoint's query count
+// doesn't grow with the number of rows (the N+1 problem).
+type QueryCounter struct {
+	count atomic.Int64
+}
+
+// Count returns the number of statements run so far
+func (c *QueryCounter) Count() int64 {
+	return c.count.Load()
+}
+
+// WithQueryCounter returns a copy of ctx that counts the statements run
+// with it, e.g. as the request context of a handler, and the counter
+func WithQueryCounter(ctx context.Context) (context.Context, *QueryCounter) {
+	counter := &QueryCounter{}
+	return context.WithValue(ctx, queryCounterKey{}, counter), counter
+}
+
+// registerQueryCounter hooks the counting of statements into db's callbacks
+func registerQueryCounter(db *gorm.DB) error {
+	callbacks := db.Callback()
+	for _, err := range []error{
+		callbacks.Create().After("gorm:create").Register("database:count_create", countQuery),
+		callbacks.Query().After("gorm:query").Register("database:count_query", countQuery),
+		callbacks.Update().After("gorm:update").Register("database:count_update", countQuery),
+		callbacks.Delete().After("gorm:delete").Register("database:count_delete", countQuery),
+		callbacks.Row().After("gorm:row").Register("database:count_row", countQuery),
+		callbacks.Raw().After("gorm:raw").Register("database:count_raw", countQuery),
+	} {
+		if err != nil {
+			return fmt.Errorf("failed to register query counter: %w", err)
+		}
+	}
+	return nil
+}
+
+// countQuery counts a statement if its context carries a QueryCounter
+func countQuery(db *gorm.DB) {
+	if counter, ok := db.Statement.Context.Value(queryCounterKey{}).(*QueryCounter); ok {
+		counter.count.Add(1)
+	}
+}
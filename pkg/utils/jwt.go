@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -12,70 +14,98 @@ import (
 // CustomClaims defines the claims structure for JWT tokens
 type CustomClaims struct {
 	UserID uint `json:"user_id"`
+	// SessionID is the login session the token belongs to. Tokens without
+	// one aren't tied to a session.
+	SessionID uint `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // GenerateToken creates a JWT token for the given user ID
 func GenerateToken(userID uint) (string, error) {
+	tokenString, _, err := GenerateTokenWithExpiry(userID, config.GetConfig().JWT.ExpiresIn)
+	return tokenString, err
+}
+
+// GenerateTokenWithExpiry creates a JWT token for the given user ID that
+// expires after d, and returns the token together with its expiry time
+func GenerateTokenWithExpiry(userID uint, d time.Duration) (string, time.Time, error) {
+	return GenerateSessionToken(userID, 0, time.Now().Add(d))
+}
+
+// GenerateSessionToken creates a JWT token for the given user ID that belongs
+// to the given session and expires at expiresAt, and returns the token
+// together with its expiry time as recorded in it
+func GenerateSessionToken(userID uint, sessionID uint, expiresAt time.Time) (string, time.Time, error) {
 	// Get JWT configuration
 	jwtConfig := config.GetConfig().JWT
 
 	// Create token claims
 	now := time.Now()
 	claims := CustomClaims{
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(jwtConfig.ExpiresIn)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
-	// Create token with claims
+	// Create token with claims, identifying the key it is signed with
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = keyID(jwtConfig.Secret)
 
 	// Sign the token with the secret key
 	tokenString, err := token.SignedString([]byte(jwtConfig.Secret))
 	if err != nil {
-		return "", fmt.Errorf("failed to sign JWT token: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to sign JWT token: %w", err)
 	}
 
-	return tokenString, nil
+	return tokenString, claims.ExpiresAt.Time, nil
 }
 
 // ValidateToken validates a JWT token and returns the user ID if valid
 func ValidateToken(tokenString string) (uint, error) {
+	claims, err := ParseToken(tokenString)
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserID, nil
+}
+
+// ParseToken validates a JWT token and returns its claims. A token whose
+// signature is valid but that has expired or isn't valid yet is returned
+// together with the error; for any other error the claims are nil.
+func ParseToken(tokenString string) (*CustomClaims, error) {
 	if tokenString == "" {
-		return 0, errors.New("empty token")
+		return nil, errors.New("empty token")
 	}
 
 	// Get JWT configuration
 	jwtConfig := config.GetConfig().JWT
 
-	// Parse and validate the token
+	// Parse and validate the token. The signature is checked before the
+	// time-based claims, so those errors imply a genuine token.
+	claims := &CustomClaims{}
 	token, err := jwt.ParseWithClaims(
 		tokenString,
-		&CustomClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			// Validate the signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(jwtConfig.Secret), nil
-		},
+		claims,
+		verificationKey(jwtConfig),
+		jwt.WithLeeway(jwtConfig.Leeway),
 	)
 
 	if err != nil {
-		return 0, fmt.Errorf("invalid token: %w", err)
+		if errors.Is(err, jwt.ErrTokenExpired) || errors.Is(err, jwt.ErrTokenNotValidYet) {
+			return claims, fmt.Errorf("invalid token: %w", err)
+		}
+		return nil, fmt.Errorf("invalid token: %w", err)
 	}
 
-	// Extract claims
-	claims, ok := token.Claims.(*CustomClaims)
-	if !ok || !token.Valid {
-		return 0, errors.New("invalid token claims")
+	if !token.Valid {
+		return nil, errors.New("invalid token claims")
 	}
 
-	return claims.UserID, nil
+	return claims, nil
 }
 
 // GetUserIDFromToken extracts the user ID from a valid JWT token
@@ -87,9 +117,8 @@ func GetUserIDFromToken(tokenString string) (uint, error) {
 	token, err := jwt.ParseWithClaims(
 		tokenString,
 		&CustomClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtConfig.Secret), nil
-		},
+		verificationKey(jwtConfig),
+		jwt.WithLeeway(jwtConfig.Leeway),
 	)
 
 	if err != nil {
@@ -105,4 +134,40 @@ func GetUserIDFromToken(tokenString string) (uint, error) {
 	}
 
 	return 0, errors.New("invalid token")
-}
\ No newline at end of file
+}
+
+// keyID returns the key ID of secret, set as the "kid" header of the tokens
+// it signs. It is derived from a hash so that it doesn't reveal the secret.
+func keyID(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:8])
+}
+
+// verificationKey returns a keyfunc accepting tokens signed with the current
+// secret or any of the verification secrets. A token's "kid" header selects
+// its key; tokens without one, issued before key IDs were added, are tried
+// against every accepted secret.
+func verificationKey(jwtConfig config.JWTConfig) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		// Validate the signing method
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		secrets := append([]string{jwtConfig.Secret}, jwtConfig.VerificationSecrets...)
+		if kid, ok := token.Header["kid"].(string); ok {
+			for _, secret := range secrets {
+				if keyID(secret) == kid {
+					return []byte(secret), nil
+				}
+			}
+			return nil, errors.New("unknown signing key")
+		}
+
+		keys := jwt.VerificationKeySet{}
+		for _, secret := range secrets {
+			keys.Keys = append(keys.Keys, []byte(secret))
+		}
+		return keys, nil
+	}
+}
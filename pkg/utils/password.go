@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"task-manager/config"
+)
+
+// generatedPasswordLength is the length of generated passwords, unless the
+// policy requires longer ones
+const generatedPasswordLength = 16
+
+// Characters used in generated passwords, by class. Look-alikes such as 0
+// and O are left out so that the password can be read out or retyped.
+const (
+	passwordLower   = "abcdefghijkmnpqrstuvwxyz"
+	passwordUpper   = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	passwordDigits  = "23456789"
+	passwordSpecial = "!#$%*+-=?@_"
+)
+
+// GeneratePassword returns a random password that satisfies the configured
+// password policy: it contains every class of character the policy can
+// require and is at least as long as the policy's minimum.
+func GeneratePassword() (string, error) {
+	length := max(generatedPasswordLength, config.GetConfig().Security.PasswordMinLength)
+	all := passwordLower + passwordUpper + passwordDigits + passwordSpecial
+
+	pw := make([]byte, 0, length)
+	for _, class := range []string{passwordLower, passwordUpper, passwordDigits, passwordSpecial} {
+		c, err := randomChar(class)
+		if err != nil {
+			return "", err
+		}
+		pw = append(pw, c)
+	}
+	for len(pw) < length {
+		c, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		pw = append(pw, c)
+	}
+
+	// Shuffle so that the required classes aren't always first
+	for i := len(pw) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+		pw[i], pw[j.Int64()] = pw[j.Int64()], pw[i]
+	}
+	return string(pw), nil
+}
+
+// randomChar returns a uniformly random character of chars
+func randomChar(chars string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate password: %w", err)
+	}
+	return chars[n.Int64()], nil
+}
+
+// ValidatePasswordStrength checks pw against the configured password policy.
+// The returned error names every rule the password breaks, e.g. "must be at
+// least 8 characters long; must contain a digit".
+func ValidatePasswordStrength(pw string) error {
+	policy := config.GetConfig().Security
+
+	var hasDigit, hasUpper, hasSpecial bool
+	for _, r := range pw {
+		switch {
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case !unicode.IsLetter(r):
+			hasSpecial = true
+		}
+	}
+
+	var problems []string
+	if utf8.RuneCountInString(pw) < policy.PasswordMinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters long", policy.PasswordMinLength))
+	}
+	if policy.PasswordRequireDigit && !hasDigit {
+		problems = append(problems, "must contain a digit")
+	}
+	if policy.PasswordRequireUpper && !hasUpper {
+		problems = append(problems, "must contain an uppercase letter")
+	}
+	if policy.PasswordRequireSpecial && !hasSpecial {
+		problems = append(problems, "must contain a character that is not a letter or digit")
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Package version holds build information injected at link time, e.g.
+//
+//	go build -ldflags "-X task-manager/pkg/version.Version=1.2.0 \
+//	  -X task-manager/pkg/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X task-manager/pkg/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package version
+
+import (
+	"runtime"
+	"time"
+)
+
+// Build information, overridden with -ldflags "-X ..."
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+// startTime is when the process started serving, set by SetStartTime
+var startTime time.Time
+
+// SetStartTime records when the process started, for reporting uptime
+func SetStartTime(t time.Time) {
+	startTime = t
+}
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	StartedAt string `json:"started_at"`
+	Uptime    string `json:"uptime"`
+}
+
+// Get returns the build information and the process uptime at now
+func Get(now time.Time) Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		StartedAt: startTime.UTC().Format(time.RFC3339),
+		Uptime:    now.Sub(startTime).Truncate(time.Second).String(),
+	}
+}